@@ -18,7 +18,9 @@ import (
 	"flag"
 	"fmt"
 	"go/ast"
+	"go/constant"
 	"go/format"
+	"go/parser"
 	"go/token"
 	"go/types"
 	"io/ioutil"
@@ -27,26 +29,96 @@ import (
 	"os/exec"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"text/template"
+	"unicode"
 
 	"golang.org/x/tools/go/packages"
 )
 
 var (
-	tableName = flag.String("table", "", "")
-	tableKey  = flag.String("key", "", "")
+	tableName = flag.String("table", "", "table `name`; used for types without a //beagle:table=<name> comment")
+	schema    = flag.String("schema", "", "`schema` (database) the tables are in, the generated queries use schema qualified table names")
+	tableKey  = flag.String("key", "", "comma-separated list of the primary key `columns`; used when no column is tagged as primary key, default the id column")
+	conflict  = flag.String("conflict-columns", "", "comma-separated list of the `columns` of the unique constraint InsertOrUpdate conflicts on with postgres and sqlite; default the key")
+	keyAuto   = flag.Bool("key-auto", false, "the integer primary key is assigned by the database; Insert omits it and sets the new id on the struct")
 
 	typeNames   = flag.String("type", "", "comma-separated list of type names; must be set")
-	output      = flag.String("output", "", "output file name; default srcdir/<type>_string.go")
+	output      = flag.String("output", "", "output file name, or - for stdout; default srcdir/<type>_gen.go")
+	outputTmpl  = flag.String("output-template", "", "`template` of the output file name in srcdir, eg. {{.Type}}_crud.go; .Types holds all type names")
 	trimprefix  = flag.String("trimprefix", "", "trim the `prefix` from the generated constant names")
+	trimsuffix  = flag.String("trimsuffix", "", "trim the `suffix` from the generated constant names, eg. _code")
 	linecomment = flag.Bool("linecomment", false, "use line comment text as printed text when present")
-	buildTags   = flag.String("tags", "", "comma-separated list of build tags to apply")
+	buildTags   tagList
+	tagName     = flag.String("tag", "db", "struct tag `key` used to look up column names")
+	inferCols   = flag.Bool("infer-columns", false, "map exported fields without a struct tag to the snake case of their name, eg. CreatedAt to created_at")
+	dialect     = flag.String("dialect", dialectMySQL, "SQL `dialect` of the generated queries: mysql, postgres or sqlite")
+	tests       = flag.Bool("tests", false, "generate round-trip tests for the CRUD methods, using github.com/DATA-DOG/go-sqlmock")
+	stringer    = flag.Bool("stringer", false, "generate a String method listing the columns and values of the types, for debug logging")
+	dbImport    = flag.String("db-import", "go.dutchsec.com/beagle/db", "import `path` of the db package used by the generated code")
+	dbAlias     = flag.String("db-alias", "db", "import `name` of the db package in the generated code, eg. when the package has a db identifier of its own")
+	check       = flag.Bool("check", false, "don't write the output, exit with status 1 and print the differences when the existing files are not up to date")
+	useImports  = flag.Bool("goimports", true, "run goimports on the generated code to add its imports; skipped when goimports is not installed")
+
+	softDeleteColumn = flag.String("softdelete-column", "active", "`column` set by Delete; when empty Delete removes the row")
+	softDeleteValue  = flag.String("softdelete-value", "", "SQL `expression` the soft delete column is set to, eg. NOW(); default 0, FALSE on postgres")
+
+	createdColumn = flag.String("created-column", "created_at", "time.Time `column` set to the current time on insert, or tag the column as db:\"<column>,created\"")
+	updatedColumn = flag.String("updated-column", "updated_at", "time.Time `column` set to the current time on insert and update, or tag the column as db:\"<column>,updated\"")
+	localTime     = flag.Bool("local-time", false, "set the created and updated columns to the local time instead of UTC")
+
+	constsOnly = flag.Bool("consts-only", false, "generate only the table and column constants and the Query<Type>s function, without the CRUD methods")
+
+	versionColumn = flag.String("version-column", "", "integer `column` used for optimistic locking; Update fails with db.ErrStaleObject when the row changed")
 )
 
+const (
+	dialectMySQL    = "mysql"
+	dialectPostgres = "postgres"
+	dialectSQLite   = "sqlite"
+)
+
+func init() {
+	flag.Var(&buildTags, "tags", "comma-separated list of build tags to apply; may be repeated")
+}
+
+// tagList is a flag accumulating build tags, separated by commas or spaces.
+type tagList []string
+
+func (l *tagList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *tagList) Set(value string) error {
+	for _, tag := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		if !validTag(tag) {
+			return fmt.Errorf("invalid build tag %q", tag)
+		}
+
+		*l = append(*l, tag)
+	}
+
+	return nil
+}
+
+// validTag reports whether the name is a valid build tag, these consist of
+// letters, digits, underscores and dots.
+func validTag(name string) bool {
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '.' {
+			return false
+		}
+	}
+
+	return name != ""
+}
+
 // Usage is a replacement usage function for the flags package.
 func Usage() {
 	fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
-	fmt.Fprintf(os.Stderr, "\tbeagle db [directory|files]\n")
+	fmt.Fprintf(os.Stderr, "\tbeagle db [directory|files...]\n")
 	fmt.Fprintf(os.Stderr, "Flags:\n")
 	flag.PrintDefaults()
 }
@@ -62,12 +134,44 @@ func main() {
 		os.Exit(2)
 	}
 
-	types := strings.Split(*typeNames, ",")
-	var tags []string
-	if len(*buildTags) > 0 {
-		tags = strings.Split(*buildTags, ",")
+	if *output == "-" && *tests {
+		log.Fatal("-tests option can not be used when writing to stdout")
+	}
+
+	if *output != "" && *outputTmpl != "" {
+		log.Fatal("-output and -output-template can not be used together")
+	}
+
+	if *constsOnly && *tests {
+		log.Fatal("-tests option can not be used with -consts-only")
+	}
+
+	switch *dialect {
+	case dialectMySQL, dialectPostgres, dialectSQLite:
+	default:
+		log.Fatalf("error: unsupported dialect %q", *dialect)
+	}
+
+	if *softDeleteValue == "" {
+		*softDeleteValue = softDeleteDefault(*dialect)
+	}
+
+	if strings.ContainsAny(*schema, "`\".") {
+		log.Fatalf("error: invalid schema %q, pass the schema name without quotes", *schema)
+	}
+
+	if !token.IsIdentifier(*dbAlias) || *dbAlias == "_" {
+		log.Fatalf("error: invalid -db-alias %q, pass a Go identifier", *dbAlias)
+	}
+
+	// check the output path before the work of parsing the package.
+	if *output != "" && *output != "-" && !*check {
+		prepareOutput(*output)
 	}
 
+	types := strings.Split(*typeNames, ",")
+	tags := []string(buildTags)
+
 	// We accept either one directory or a list of files. Which do we have?
 	args := flag.Args()
 	if len(args) == 0 {
@@ -79,44 +183,110 @@ func main() {
 	var dir string
 	g := Generator{
 		trimPrefix:  *trimprefix,
+		trimSuffix:  *trimsuffix,
 		lineComment: *linecomment,
+		tagName:     *tagName,
+		dialect:     *dialect,
+		inferCols:   *inferCols,
+		schema:      *schema,
+
+		softDeleteColumn: *softDeleteColumn,
+		softDeleteValue:  *softDeleteValue,
+
+		createdColumn: *createdColumn,
+		updatedColumn: *updatedColumn,
+		localTime:     *localTime,
+
+		versionColumn: *versionColumn,
+
+		keyAuto: *keyAuto,
+
+		constsOnly: *constsOnly,
+		stringer:   *stringer,
+
+		dbAlias: *dbAlias,
+	}
+
+	for _, key := range strings.Split(*tableKey, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			g.tableKeys = append(g.tableKeys, key)
+		}
 	}
 
-	// TODO(suzmue): accept other patterns for packages (directories, list of files, import paths, etc).
+	for _, column := range strings.Split(*conflict, ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			g.conflictColumns = append(g.conflictColumns, column)
+		}
+	}
+
+	// TODO(suzmue): accept other patterns for packages (import paths, etc).
 	if len(args) == 1 && isDirectory(args[0]) {
 		dir = args[0]
 	} else {
 		if len(tags) != 0 {
 			log.Fatal("-tags option applies only to directories, not when files are specified")
 		}
+
+		// the files are parsed together as one package, the output
+		// goes next to them.
 		dir = filepath.Dir(args[0])
+		for _, arg := range args[1:] {
+			if filepath.Dir(arg) != dir {
+				log.Fatalf("error: files of one package must be in one directory, %s is not in %s", arg, dir)
+			}
+		}
 	}
 
 	g.parsePackage(args, tags)
 
-	// Print the header and package clause.
-	g.Printf("// Code generated by \"beagle db %s\"; DO NOT EDIT.\n", strings.Join(os.Args[1:], " "))
-	g.Printf("\n")
-	g.Printf("package %s", g.pkg.name)
-	g.Printf("\n")
-	g.Printf(`import (
-db "go.dutchsec.com/beagle/db"
-)
-`) // Used by all methods.
-
 	// Run generate for each type.
 	for _, typeName := range types {
 		g.generate(typeName)
 	}
 
+	// The imports depend on the generated code, print the header and
+	// package clause before it.
+	body := g.buf.String()
+	g.buf.Reset()
+
+	g.Printf("// Code generated by \"beagle db %s\"; DO NOT EDIT.\n", strings.Join(commandArgs(os.Args[1:]), " "))
+	g.Printf("\n")
+	g.Printf("package %s", g.pkg.name)
+	g.Printf("\n")
+	g.Printf("import (\n")
+	if g.writes || g.usesFmt {
+		g.Printf("\"fmt\"\n")
+		g.Printf("\"strings\"\n")
+	}
+	if g.writes {
+		if g.usesTime {
+			g.Printf("\"time\"\n")
+		}
+		g.Printf("\n")
+		g.Printf("\"github.com/jmoiron/sqlx\"\n")
+	}
+	g.Printf("db %q\n", *dbImport)
+	g.Printf(")\n")
+
+	g.Printf("%s", body)
+
 	// Format the output.
 	src := g.format()
 
 	// Write to file.
 	outputName := *output
-	if outputName == "" {
-		baseName := fmt.Sprintf("%s_gen.go", types[0])
-		outputName = filepath.Join(dir, strings.ToLower(baseName))
+	if *outputTmpl != "" {
+		name, err := outputFileName(*outputTmpl, types)
+		if err != nil {
+			log.Fatalf("error: -output-template: %s", err)
+		}
+
+		outputName = filepath.Join(dir, name)
+		if !*check {
+			prepareOutput(outputName)
+		}
+	} else if outputName == "" {
+		outputName = filepath.Join(dir, defaultOutputName(types[0]))
 	}
 
 	var err error
@@ -126,8 +296,208 @@ db "go.dutchsec.com/beagle/db"
 		log.Fatalf("Error executing goimport: %s", err.Error())
 	}
 
-	if err := ioutil.WriteFile(outputName, src, 0644); err != nil {
-		log.Fatalf("writing output: %s", err)
+	writeOutput(outputName, src)
+
+	if !*tests {
+		exitIfStale()
+		return
+	}
+
+	t := Generator{
+		pkg:     g.pkg,
+		dialect: g.dialect,
+
+		softDeleteColumn: g.softDeleteColumn,
+
+		versionColumn: g.versionColumn,
+
+		tableKeys: g.tableKeys,
+		keyAuto:   g.keyAuto,
+
+		dbAlias: g.dbAlias,
+	}
+
+	t.Printf("// Code generated by \"beagle db %s\"; DO NOT EDIT.\n", strings.Join(commandArgs(os.Args[1:]), " "))
+	t.Printf("\n")
+	t.Printf("package %s", g.pkg.name)
+	t.Printf("\n")
+	t.Printf(`import (
+"database/sql/driver"
+"regexp"
+"testing"
+
+sqlmock "github.com/DATA-DOG/go-sqlmock"
+"github.com/jmoiron/sqlx"
+db %q
+)
+`, *dbImport)
+
+	for _, typeName := range types {
+		t.generateTest(typeName)
+	}
+
+	// views have no methods to test
+	if !t.writes {
+		exitIfStale()
+		return
+	}
+
+	testName := strings.TrimSuffix(outputName, ".go") + "_test.go"
+
+	src, err = goimports(testName, t.format())
+	if err != nil {
+		log.Fatalf("Error executing goimport: %s", err.Error())
+	}
+
+	writeOutput(testName, src)
+
+	exitIfStale()
+}
+
+// stale is set when -check finds an output file that is not up to date.
+var stale bool
+
+// writeOutput writes the generated source to the named file, or stdout for
+// -. With -check the file is compared with the source instead, the
+// differences are printed.
+func writeOutput(name string, src []byte) {
+	if name == "-" {
+		if _, err := os.Stdout.Write(src); err != nil {
+			log.Fatalf("writing output: %s", err)
+		}
+
+		return
+	}
+
+	if !*check {
+		if err := ioutil.WriteFile(name, src, 0644); err != nil {
+			log.Fatalf("writing output: %s", err)
+		}
+
+		return
+	}
+
+	current, err := ioutil.ReadFile(name)
+	if err == nil && bytes.Equal(current, src) {
+		return
+	}
+
+	stale = true
+
+	if err != nil {
+		fmt.Printf("%s: %s\n", name, err)
+		return
+	}
+
+	fmt.Printf("%s is not up to date\n", name)
+	printDiff(name, src)
+}
+
+// printDiff prints the differences of the file with the source, using diff
+// when it is installed.
+func printDiff(name string, src []byte) {
+	if _, err := exec.LookPath("diff"); err != nil {
+		return
+	}
+
+	f, err := ioutil.TempFile("", "beagle-db")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(src); err != nil {
+		log.Fatal(err)
+	}
+
+	f.Close()
+
+	// diff exits with status 1 when the files differ
+	out, _ := exec.Command("diff", "-u", "--label", name, "--label", name+" (generated)", name, f.Name()).Output()
+	os.Stdout.Write(out)
+}
+
+// exitIfStale exits with status 1 when -check found outdated files.
+func exitIfStale() {
+	if stale {
+		os.Exit(1)
+	}
+}
+
+// commandArgs returns the arguments for the header of the generated files,
+// without -check, so checking doesn't change the header.
+func commandArgs(args []string) []string {
+	out := []string{}
+	for _, arg := range args {
+		switch strings.TrimLeft(arg, "-") {
+		case "check", "check=true", "check=false":
+			if strings.HasPrefix(arg, "-") {
+				continue
+			}
+		}
+
+		out = append(out, arg)
+	}
+
+	return out
+}
+
+// softDeleteDefault returns the value Delete sets the soft delete column to
+// without -softdelete-value, postgres doesn't assign an integer to a boolean
+// column.
+func softDeleteDefault(dialect string) string {
+	if dialect == dialectPostgres {
+		return "FALSE"
+	}
+
+	return "0"
+}
+
+// defaultOutputName returns the output file name of the type without
+// -output and -output-template, the type name in its original case, eg.
+// UserRole_gen.go for UserRole.
+func defaultOutputName(typeName string) string {
+	return typeName + "_gen.go"
+}
+
+// outputFileName returns the output file name of the types from the
+// template. The template gets the first type as .Type, all types as .Types
+// and the functions lower, snake and join.
+func outputFileName(tmpl string, types []string) (string, error) {
+	t, err := template.New("output").Funcs(template.FuncMap{
+		"lower": strings.ToLower,
+		"snake": snakeCase,
+		"join":  strings.Join,
+	}).Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, struct {
+		Type  string
+		Types []string
+	}{types[0], types}); err != nil {
+		return "", err
+	}
+
+	if b.Len() == 0 || !strings.HasSuffix(b.String(), ".go") {
+		return "", fmt.Errorf("file name %q is not a .go file", b.String())
+	}
+
+	return b.String(), nil
+}
+
+// prepareOutput creates the parent directories of the output file, it
+// fails when the output is a directory.
+func prepareOutput(name string) {
+	if info, err := os.Stat(name); err == nil && info.IsDir() {
+		log.Fatalf("error: output %s is a directory, pass a file name", name)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		log.Fatalf("error: creating the output directory: %s", err)
 	}
 }
 
@@ -147,13 +517,86 @@ type Generator struct {
 	pkg *Package     // Package we are scanning.
 
 	trimPrefix  string
+	trimSuffix  string
 	lineComment bool
+	tagName     string
+	dialect     string
+	schema      string
+	inferCols   bool // Whether fields without a struct tag are columns.
+
+	softDeleteColumn string
+	softDeleteValue  string
+
+	createdColumn string
+	updatedColumn string
+	localTime     bool // Whether timestamps are set to the local time instead of UTC.
+
+	versionColumn string
+
+	tableKeys []string
+	keyAuto   bool
+
+	conflictColumns []string // Unique columns InsertOrUpdate conflicts on.
+
+	constsOnly bool // Whether to generate the constants and Query<Type>s only.
+	stringer   bool // Whether to generate the String methods.
+
+	writes bool // Whether the CRUD methods of a type were generated.
+
+	dbAlias string // Import name of the db package, the code is generated using db.
+
+	usesTime bool // Whether the generated code uses the time package.
+	usesFmt  bool // Whether the String methods use the fmt and strings packages.
+
+	enums map[*types.TypeName]bool // Enum types whose names are generated.
 }
 
 func (g *Generator) Printf(format string, args ...interface{}) {
 	fmt.Fprintf(&g.buf, format, args...)
 }
 
+// quote returns the identifier quoted for the configured dialect, quotes
+// within the identifier are doubled. The result is escaped to be embedded
+// in a generated Go string literal.
+func (g *Generator) quote(name string) string {
+	q := "`"
+	if g.dialect == dialectPostgres || g.dialect == dialectSQLite {
+		q = `"`
+	}
+
+	ident := q + strings.Replace(name, q, q+q, -1) + q
+
+	literal := strconv.Quote(ident)
+	return literal[1 : len(literal)-1]
+}
+
+// quoteTable returns the quoted table name, qualified with the schema when
+// one is configured.
+func (g *Generator) quoteTable(table string) string {
+	if g.schema == "" {
+		return g.quote(table)
+	}
+
+	return g.quote(g.schema) + "." + g.quote(table)
+}
+
+// validColumn reports whether the name can be used as column. Columns are
+// bound by name (:column), so they are limited to the characters sqlx
+// allows in bind names.
+func validColumn(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '.' {
+			return false
+		}
+	}
+
+	return true
+}
+
 // File holds a single parsed file and associated data.
 type File struct {
 	pkg  *Package  // Package to which this file belongs.
@@ -162,10 +605,66 @@ type File struct {
 	typeName string  // Name of the constant type.
 	values   []Value // Accumulator for constant values of that type.
 
-	types map[string][]string
+	types  map[string][]Column
+	tables map[string]string // Table names from the //beagle:table= comments.
+	views  map[string]bool   // Types marked read-only by //beagle:view comments.
 
 	trimPrefix  string
 	lineComment bool
+	tagName     string
+	inferCols   bool
+}
+
+// Column is a database column mapped to a field of the struct type.
+type Column struct {
+	name    string     // Name of the column, from the struct tag.
+	field   string     // Selector of the struct field, eg. Base.CreatedAt.
+	options []string   // Options following the name in the struct tag.
+	key     bool       // Whether the column is (part of) the primary key.
+	typ     types.Type // Type of the struct field.
+
+	// Selectors of the embedded pointers the field is reached through, and
+	// the types they point to.
+	pointers     []string
+	pointerElems []types.Type
+}
+
+// isTime reports whether the struct field is a time.Time.
+func (c Column) isTime() bool {
+	named, ok := c.typ.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return false
+	}
+
+	return named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Time"
+}
+
+// isInteger reports whether the column holds an integer.
+func (c Column) isInteger() bool {
+	basic, ok := c.typ.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsInteger != 0
+}
+
+// notNil returns the conditions that the embedded pointers the field is
+// reached through are not nil, the field can't be read or set otherwise.
+func (c Column) notNil() []string {
+	conds := make([]string, len(c.pointers))
+	for i, pointer := range c.pointers {
+		conds[i] = fmt.Sprintf("s.%s != nil", pointer)
+	}
+
+	return conds
+}
+
+// hasOption reports whether the column is tagged with the option.
+func (c Column) hasOption(option string) bool {
+	for _, o := range c.options {
+		if o == option {
+			return true
+		}
+	}
+
+	return false
 }
 
 type Package struct {
@@ -178,19 +677,29 @@ type Package struct {
 
 // addPackage adds a type checked Package and its syntax files to the generator.
 func (g *Generator) addPackage(pkg *packages.Package) {
+	g.setPackage(pkg.Name, pkg.TypesInfo.Defs, pkg.Syntax)
+}
+
+// setPackage sets the package to generate code for from its parsed and
+// type-checked files.
+func (g *Generator) setPackage(name string, defs map[*ast.Ident]types.Object, syntax []*ast.File) {
 	g.pkg = &Package{
-		name:  pkg.Name,
-		defs:  pkg.TypesInfo.Defs,
-		files: make([]*File, len(pkg.Syntax)),
+		name:  name,
+		defs:  defs,
+		files: make([]*File, len(syntax)),
 	}
 
-	for i, file := range pkg.Syntax {
+	for i, file := range syntax {
 		g.pkg.files[i] = &File{
 			file:        file,
 			pkg:         g.pkg,
 			trimPrefix:  g.trimPrefix,
 			lineComment: g.lineComment,
-			types:       map[string][]string{},
+			tagName:     g.tagName,
+			inferCols:   g.inferCols,
+			types:       map[string][]Column{},
+			tables:      map[string]string{},
+			views:       map[string]bool{},
 		}
 	}
 }
@@ -215,7 +724,7 @@ func (g *Generator) parsePackage(patterns []string, tags []string) {
 		// TODO: Need to think about constants in test files. Maybe write type_string_test.go
 		// in a separate pass? For later.
 		Tests:      false,
-		BuildFlags: []string{fmt.Sprintf("-tags=%s", strings.Join(tags, " "))},
+		BuildFlags: []string{fmt.Sprintf("-tags=%s", strings.Join(tags, ","))},
 	}
 	pkgs, err := packages.Load(cfg, patterns...)
 	if err != nil {
@@ -243,165 +752,616 @@ func (f *File) genDecl(node ast.Node) bool {
 				continue
 			}
 
-			columns := []string{}
+			columns := []Column{}
 			if st, ok := ts.Type.(*ast.StructType); ok {
 				for _, field := range st.Fields.List {
-					if field.Tag == nil {
-						continue
+					tag := ""
+					if field.Tag != nil {
+						tag = field.Tag.Value
+						tag = strings.TrimPrefix(tag, "`")
+						tag = strings.TrimSuffix(tag, "`")
 					}
 
-					tag := field.Tag.Value
-					tag = strings.TrimPrefix(tag, "`")
-					tag = strings.TrimSuffix(tag, "`")
+					if len(field.Names) == 0 {
+						// embedded field, the identifier of its type is
+						// defined as the field.
+						ident := embeddedIdent(field.Type)
+						if obj, ok := f.pkg.defs[ident]; ok {
+							columns = append(columns, f.embeddedColumns(obj.Type(), tag, "", ident.Name+".")...)
+						}
+
+						continue
+					}
 
-					value, ok := reflect.StructTag(tag).Lookup("db")
+					value, ok := f.columnTag(tag, field.Names[0].Name)
 					if !ok {
 						continue
 					}
 
-					columns = append(columns, value)
+					var typ types.Type
+					if obj, ok := f.pkg.defs[field.Names[0]]; ok {
+						typ = obj.Type()
+					}
+
+					columns = append(columns, f.newColumn(value, field.Names[0].Name, typ, tag))
 				}
+			} else if obj, ok := f.pkg.defs[ts.Name]; ok {
+				// aliases and other types of a struct, eg.
+				// type Alert = internalAlert, have the exported fields of
+				// the struct as columns.
+				if named, ok := obj.Type().(*types.Named); ok && ts.Assign.IsValid() && named.Obj().Pkg() != obj.Pkg() {
+					log.Fatalf("error: type %s is an alias of %s of another package, it can't have methods", typ, named)
+				}
+
+				columns = f.embeddedColumns(obj.Type(), "", "", "")
+			}
+
+			fields := map[string]string{}
+			for _, column := range columns {
+				if !validColumn(column.name) {
+					log.Fatalf("error: invalid column name %q in type %s", column.name, typ)
+				}
+
+				if field, ok := fields[column.name]; ok {
+					log.Fatalf("error: duplicate column %q in type %s, tagged on the fields %s and %s", column.name, typ, field, column.field)
+				}
+
+				fields[column.name] = column.field
+			}
+
+			if len(columns) == 0 {
+				log.Fatalf("error: no columns found for type %s using struct tag %q", typ, f.tagName)
 			}
 
 			f.types[typ] = columns
+
+			if table := tableDirective(ts.Doc, decl.Doc); table != "" {
+				f.tables[typ] = table
+			}
+
+			f.views[typ] = viewDirective(ts.Doc, decl.Doc)
 		}
 	}
 
 	return false
 }
 
-// generate produces the String method for the named type.
-func (g *Generator) generate(typeName string) {
-	values := make([]Value, 0, 100)
-	for _, file := range g.pkg.files {
-		// Set the state for this run of the walker.
-		file.typeName = typeName
-		file.values = nil
-		if file.file != nil {
-			ast.Inspect(file.file, file.genDecl)
-			values = append(values, file.values...)
-		}
-
-		if len(file.types) == 0 {
+// tableDirective returns the table name of a //beagle:table=<name> comment
+// line in the comment groups, the first group containing one wins.
+func tableDirective(groups ...*ast.CommentGroup) string {
+	for _, group := range groups {
+		if group == nil {
 			continue
 		}
 
-		nameize := func(name string) string {
-			value := ""
-
-			parts := strings.Split(name, "_")
-			for _, part := range parts {
-				if part == "id" {
-					value += "ID"
-					continue
-				}
-
-				value += strings.Title(part)
+		for _, comment := range group.List {
+			if table := strings.TrimPrefix(comment.Text, "//beagle:table="); table != comment.Text {
+				return strings.TrimSpace(table)
 			}
-
-			return value
 		}
+	}
 
-		g.Printf("var (\n")
+	return ""
+}
 
-		for name, columns := range file.types {
-			g.Printf("%s%s db.Table = \"`%s`\"\n", name, nameize(*tableName), *tableName)
-			for _, column := range columns {
-				g.Printf("%s%s db.Field = \"`%s`.`%s`\"\n", name, nameize(column), *tableName, column)
+// viewDirective reports whether the comment groups hold a //beagle:view
+// comment line, marking the type as a read-only view.
+func viewDirective(groups ...*ast.CommentGroup) bool {
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+
+		for _, comment := range group.List {
+			if strings.TrimSpace(comment.Text) == "//beagle:view" {
+				return true
 			}
 		}
-		g.Printf(")\n")
+	}
 
-		for name, columns := range file.types {
-			g.Printf("var (\n")
+	return false
+}
 
-			g.Printf("query%sDelete db.Query = \"UPDATE %s SET active = 0 ", name, *tableName)
-			g.Printf(" WHERE `%s`=:%s\"", *tableKey, *tableKey)
-			g.Printf("\n")
+// table returns the table name of the type, from its //beagle:table=
+// comment or the -table flag.
+func (f *File) table(typeName string) string {
+	if table, ok := f.tables[typeName]; ok {
+		return table
+	}
 
-			g.Printf("query%sSelect db.Query = \"SELECT ", name)
+	if *tableName == "" {
+		log.Fatalf("error: no table for type %s, pass -table or add a //beagle:table=<name> comment", typeName)
+	}
+
+	return *tableName
+}
+
+// columnTag returns the struct tag value of the column of a field, false
+// when the field isn't a column. Fields tagged "-" are skipped, with
+// -infer-columns exported fields without a tag are columns named after the
+// snake case of the field name.
+func (f *File) columnTag(tag string, name string) (string, bool) {
+	value, ok := reflect.StructTag(tag).Lookup(f.tagName)
+	if !ok && f.inferCols && ast.IsExported(name) {
+		return snakeCase(name), true
+	}
+
+	if value == "-" {
+		return "", false
+	}
+
+	return value, ok
+}
+
+// snakeCase returns the name in snake case, an initialism is a single
+// word, eg. UserID is user_id and HTTPStatus is http_status.
+func snakeCase(name string) string {
+	runes := []rune(name)
+
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			// a word starts after a lower case letter or digit, or at the
+			// last capital of an initialism.
+			if !unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteRune('_')
+			}
+		}
+
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return b.String()
+}
+
+// newColumn returns the column for a struct field, value is the struct tag
+// value and holds the column name followed by its options.
+func (f *File) newColumn(value string, field string, typ types.Type, tag string) Column {
+	parts := strings.Split(value, ",")
+
+	column := Column{
+		name:    parts[0],
+		field:   field,
+		options: parts[1:],
+		typ:     typ,
+	}
+
+	// the key is either tagged as db:"id,primary" or
+	// beagle:"pk".
+	column.key = column.hasOption("primary")
+	if value, ok := reflect.StructTag(tag).Lookup("beagle"); ok && value == "pk" {
+		column.key = true
+	}
+
+	return column
+}
+
+// embeddedColumns returns the columns of an embedded struct. Like sqlx, the
+// columns are promoted to the embedding struct, prefixed with the name
+// tagged on the embedded field (if any). The fields are selected through
+// path, as a promoted field can be shadowed by the embedding struct.
+func (f *File) embeddedColumns(typ types.Type, tag string, prefix string, path string) []Column {
+	value, _ := reflect.StructTag(tag).Lookup(f.tagName)
+	if value == "-" {
+		return nil
+	}
+
+	if name := strings.Split(value, ",")[0]; name != "" {
+		prefix = prefix + name + "."
+	}
+
+	ptr, isPointer := typ.Underlying().(*types.Pointer)
+	if isPointer {
+		typ = ptr.Elem()
+	}
+
+	st, ok := typ.Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+
+	columns := []Column{}
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+
+		if field.Embedded() {
+			columns = append(columns, f.embeddedColumns(field.Type(), st.Tag(i), prefix, path+field.Name()+".")...)
+			continue
+		} else if !field.Exported() {
+			continue
+		}
+
+		value, ok := f.columnTag(st.Tag(i), field.Name())
+		if !ok {
+			continue
+		}
+
+		columns = append(columns, f.newColumn(prefix+value, path+field.Name(), field.Type(), st.Tag(i)))
+	}
+
+	if isPointer {
+		for i := range columns {
+			columns[i].pointers = append([]string{strings.TrimSuffix(path, ".")}, columns[i].pointers...)
+			columns[i].pointerElems = append([]types.Type{typ}, columns[i].pointerElems...)
+		}
+	}
+
+	return columns
+}
+
+// embeddedIdent returns the identifier of the type of an embedded field.
+func embeddedIdent(expr ast.Expr) *ast.Ident {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e
+	case *ast.StarExpr:
+		return embeddedIdent(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel
+	}
+
+	return nil
+}
+
+// nameize returns the Go name for a table or column name, with the prefix
+// given by -trimprefix and the suffix given by -trimsuffix removed.
+func (g *Generator) nameize(name string) string {
+	if trimmed := strings.TrimPrefix(name, g.trimPrefix); trimmed != "" {
+		name = trimmed
+	}
+
+	if trimmed := strings.TrimSuffix(name, g.trimSuffix); trimmed != "" {
+		name = trimmed
+	}
+
+	value := ""
+
+	// columns of embedded structs can be prefixed, eg. audit.by
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '.'
+	})
+	for _, part := range parts {
+		if part == "id" {
+			value += "ID"
+			continue
+		}
+
+		value += strings.Title(part)
+	}
+
+	return value
+}
+
+// generate produces the String method for the named type.
+func (g *Generator) generate(typeName string) {
+	values := make([]Value, 0, 100)
+	for _, file := range g.pkg.files {
+		// Set the state for this run of the walker.
+		file.typeName = typeName
+		file.values = nil
+		if file.file != nil {
+			ast.Inspect(file.file, file.genDecl)
+			values = append(values, file.values...)
+		}
+
+		if _, ok := file.types[typeName]; !ok {
+			continue
+		}
+
+		g.Printf("var (\n")
+
+		// the types of earlier runs are kept for generateTest, only
+		// generate the type of this run.
+		for name, columns := range file.types {
+			if name != typeName {
+				continue
+			}
+
+			table := file.table(name)
+			if strings.ContainsAny(table, "`\"") {
+				log.Fatalf("error: invalid table %q of type %s, pass the table name without quotes", table, name)
+			}
+
+			g.Printf("%s%s db.Table = \"%s\"\n", name, g.nameize(table), g.quoteTable(table))
+			for _, column := range columns {
+				g.Printf("%s%s db.Field = \"%s.%s\"\n", name, g.nameize(column.name), g.quoteTable(table), g.quote(column.name))
+			}
+		}
+		g.Printf(")\n")
+
+		for name, columns := range file.types {
+			if name != typeName {
+				continue
+			}
+
+			table := file.table(name)
+
+			g.printEnums(columns)
+
+			if g.constsOnly {
+				g.printQuery(name, table, columns)
+				continue
+			}
+
+			// views can't be written, only selected and counted
+			if file.views[name] {
+				g.printQuery(name, table, columns)
+				g.printCount(name, table, columns)
+				g.printString(name, columns)
+				continue
+			}
+
+			g.writes = true
+
+			keys := g.keys(columns)
+			if len(keys) == 0 {
+				log.Fatalf("error: no key found for type %s, tag the key column with `%s:\"<column>,primary\"`, pass -key or add an id column", name, g.tagName)
+			}
+
+			for _, key := range keys {
+				if !hasColumn(columns, key) {
+					log.Fatalf("error: key column %q not found in type %s, the columns are: %s", key, name, strings.Join(columnNames(columns), ", "))
+				}
+			}
+
+			// the insert or update conflicts on the key, unless another
+			// unique constraint is given.
+			conflictColumns := keys
+			if len(g.conflictColumns) > 0 {
+				conflictColumns = g.conflictColumns
+			}
+
+			for _, column := range conflictColumns {
+				if !hasColumn(columns, column) {
+					log.Fatalf("error: conflict column %q not found in type %s, the columns are: %s", column, name, strings.Join(columnNames(columns), ", "))
+				}
+			}
+
+			// readonly columns, eg. generated columns, are selected but
+			// never written.
+			writeColumns := writableColumns(columns)
+			if len(writeColumns) == 0 {
+				log.Fatalf("error: all columns of type %s are readonly, mark it as a view with a //beagle:view comment", name)
+			}
+
+			hasDelete := g.canDelete(columns)
+			if !hasDelete {
+				log.Printf("warning: soft delete column %q not found in type %s, skipping Delete", g.softDeleteColumn, name)
+			}
+
+			version, hasVersion := findColumn(columns, g.versionColumn)
+			if g.versionColumn != "" && !hasVersion {
+				log.Printf("warning: version column %q not found in type %s, Update is not locked", g.versionColumn, name)
+			}
+
+			if hasVersion && !version.isInteger() {
+				log.Fatalf("error: version column %q of type %s is not an integer", version.name, name)
+			}
+
+			g.Printf("var (\n")
+
+			if hasDelete {
+				if g.softDeleteColumn != "" {
+					g.Printf("query%sDelete db.Query = \"UPDATE %s SET %s = %s", name, g.quoteTable(table), g.quote(g.softDeleteColumn), g.softDeleteValue)
+				} else {
+					g.Printf("query%sDelete db.Query = \"DELETE FROM %s", name, g.quoteTable(table))
+				}
+
+				g.Printf(" WHERE %s\"", g.whereKeys(keys))
+				g.Printf("\n")
+
+				// deletes the rows of a list of keys, expanded by
+				// sqlx.In.
+				if len(keys) == 1 {
+					if g.softDeleteColumn != "" {
+						g.Printf("query%sDeleteMany db.Query = \"UPDATE %s SET %s = %s", name, g.quoteTable(table), g.quote(g.softDeleteColumn), g.softDeleteValue)
+					} else {
+						g.Printf("query%sDeleteMany db.Query = \"DELETE FROM %s", name, g.quoteTable(table))
+					}
+
+					g.Printf(" WHERE %s IN (?)\"", g.quote(keys[0]))
+					g.Printf("\n")
+				}
+			}
+
+			g.Printf("query%sDeleteHard db.Query = \"DELETE FROM %s", name, g.quoteTable(table))
+			g.Printf(" WHERE %s\"", g.whereKeys(keys))
+			g.Printf("\n")
+
+			g.Printf("query%sSelect db.Query = \"SELECT ", name)
 			for i, column := range columns {
 				if i > 0 {
 					g.Printf(", ")
 				}
 
-				g.Printf("`%s`", column)
+				g.Printf("%s", g.quote(column.name))
 			}
 
-			g.Printf(" FROM %s\"", *tableName)
+			g.Printf(" FROM %s\"", g.quoteTable(table))
 			g.Printf("\n")
 
-			g.Printf("query%sUpdate db.Query = \"UPDATE %s SET ", name, *tableName)
-			for i, column := range columns {
+			g.Printf("query%sGetByKey db.Query = query%sSelect + \" WHERE %s\"", name, name, g.whereKeys(keys))
+			g.Printf("\n")
+
+			for _, column := range columns {
+				if column.hasOption("unique") {
+					g.Printf("query%sGetBy%s db.Query = query%sSelect + \" WHERE %s\"", name, g.nameize(column.name), name, g.whereKeys([]string{column.name}))
+					g.Printf("\n")
+				}
+			}
+
+			g.Printf("query%sExists db.Query = \"SELECT 1 FROM %s WHERE %s LIMIT 1\"", name, g.quoteTable(table), g.whereKeys(keys))
+			g.Printf("\n")
+
+			g.Printf("query%sCount db.Query = \"SELECT COUNT(*) FROM %s\"", name, g.quoteTable(table))
+			g.Printf("\n")
+
+			g.Printf("query%sUpdate db.Query = \"UPDATE %s SET ", name, g.quoteTable(table))
+			for i, column := range writeColumns {
 				if i > 0 {
 					g.Printf(", ")
 				}
 
-				g.Printf("`%s`=:%s", column, column)
+				if hasVersion && column.name == version.name {
+					g.Printf("%s=%s+1", g.quote(column.name), g.quote(column.name))
+					continue
+				}
+
+				g.Printf("%s=:%s", g.quote(column.name), column.name)
+			}
+
+			g.Printf(" WHERE %s", g.whereKeys(keys))
+			if hasVersion {
+				g.Printf(" AND %s=:%s", g.quote(version.name), version.name)
 			}
 
-			g.Printf(" WHERE %s=:%s	\"", *tableKey, *tableKey)
+			g.Printf("\"")
 			g.Printf("\n")
 
-			g.Printf("query%sInsert db.Query = \"INSERT INTO %s (", name, *tableName)
-			for i, column := range columns {
+			// the auto key is assigned by the database, Insert leaves it
+			// out and reads it back.
+			insertColumns := writeColumns
+
+			var autoKey Column
+			if g.keyAuto {
+				autoKey, insertColumns = g.autoKey(name, columns, keys)
+				insertColumns = writableColumns(insertColumns)
+			}
+
+			g.Printf("query%sInsert db.Query = \"INSERT INTO %s (", name, g.quoteTable(table))
+			for i, column := range insertColumns {
 				if i > 0 {
 					g.Printf(", ")
 				}
 
-				g.Printf("`%s`", column)
+				g.Printf("%s", g.quote(column.name))
 			}
 
 			g.Printf(") VALUES (")
-			for i, column := range columns {
+			for i, column := range insertColumns {
+				if i > 0 {
+					g.Printf(", ")
+				}
+
+				g.Printf(":%s", column.name)
+			}
+
+			g.Printf(")")
+			if g.keyAuto && g.dialect == dialectPostgres {
+				g.Printf(" RETURNING %s", g.quote(autoKey.name))
+			}
+
+			g.Printf("\"")
+			g.Printf("\n")
+
+			// inserts the row, unless it conflicts with an existing row
+			insertNames := make([]string, len(insertColumns))
+			insertBinds := make([]string, len(insertColumns))
+			for i, column := range insertColumns {
+				insertNames[i] = g.quote(column.name)
+				insertBinds[i] = ":" + column.name
+			}
+
+			if g.dialect == dialectPostgres || g.dialect == dialectSQLite {
+				g.Printf("query%sInsertIgnore db.Query = \"INSERT INTO %s (%s) VALUES (%s) ON CONFLICT DO NOTHING\"", name, g.quoteTable(table), strings.Join(insertNames, ", "), strings.Join(insertBinds, ", "))
+			} else {
+				g.Printf("query%sInsertIgnore db.Query = \"INSERT IGNORE INTO %s (%s) VALUES (%s)\"", name, g.quoteTable(table), strings.Join(insertNames, ", "), strings.Join(insertBinds, ", "))
+			}
+
+			g.Printf("\n")
+
+			g.Printf("query%sInsertMany db.Query = \"INSERT INTO %s (", name, g.quoteTable(table))
+			for i, column := range insertColumns {
 				if i > 0 {
 					g.Printf(", ")
 				}
 
-				g.Printf(":%s", column)
+				g.Printf("%s", g.quote(column.name))
 			}
 
-			g.Printf(")\"")
+			g.Printf(") VALUES \"")
 			g.Printf("\n")
 
-			g.Printf("query%sInsertOrUpdate db.Query = \"INSERT INTO %s (", name, *tableName)
-			for i, column := range columns {
+			g.Printf("query%sInsertOrUpdate db.Query = \"INSERT INTO %s (", name, g.quoteTable(table))
+			for i, column := range writeColumns {
 				if i > 0 {
 					g.Printf(", ")
 				}
 
-				g.Printf("`%s`", column)
+				g.Printf("%s", g.quote(column.name))
 			}
 
 			g.Printf(") VALUES (")
-			for i, column := range columns {
+			for i, column := range writeColumns {
 				if i > 0 {
 					g.Printf(", ")
 				}
 
-				g.Printf(":%s", column)
+				g.Printf(":%s", column.name)
 			}
 
-			g.Printf(") ON DUPLICATE KEY UPDATE ")
+			if g.dialect == dialectPostgres || g.dialect == dialectSQLite {
+				conflict := make([]string, len(conflictColumns))
+				for i, column := range conflictColumns {
+					conflict[i] = g.quote(column)
+				}
 
-			for i, column := range columns {
-				if column == "created_at" {
+				g.Printf(") ON CONFLICT (%s) DO UPDATE SET ", strings.Join(conflict, ", "))
+			} else {
+				g.Printf(") ON DUPLICATE KEY UPDATE ")
+			}
+
+			// the created timestamp keeps the time of the insert
+			set := []string{}
+			for _, column := range writeColumns {
+				if g.isCreated(column) {
 					continue
 				}
 
-				if i > 0 {
-					g.Printf(", ")
+				// keep the current value when the field is NULL
+				if column.hasOption("omitempty") {
+					set = append(set, fmt.Sprintf("%s=COALESCE(:%s, %s.%s)", g.quote(column.name), column.name, g.quoteTable(table), g.quote(column.name)))
+					continue
 				}
 
-				g.Printf("`%s`=:%s", column, column)
+				set = append(set, fmt.Sprintf("%s=:%s", g.quote(column.name), column.name))
 			}
 
-			g.Printf("\"")
+			g.Printf("%s\"", strings.Join(set, ", "))
 
 			g.Printf("\n")
 
+			// postgres returns the stored row, mysql selects it after
+			// the InsertOrUpdate.
+			if g.dialect == dialectPostgres {
+				returning := make([]string, len(columns))
+				for i, column := range columns {
+					returning[i] = g.quote(column.name)
+				}
+
+				g.Printf("query%sInsertOrUpdateReturning db.Query = query%sInsertOrUpdate + \" RETURNING %s\"", name, name, strings.Join(returning, ", "))
+				g.Printf("\n")
+			}
+
 			g.Printf(")\n")
 
+			// sqlx can't bind or scan fields of JSON columns, these are
+			// bound from a map and scanned in the order of the columns.
+			arg := "s"
+			getRow := "stmt.Get(s, params...)"
+			getRowByKey := "stmt.Get(s, s)"
+			if hasJSON(columns) {
+				arg = "s.namedValues()"
+				getRow = "stmt.QueryRowx(params...).Scan(s.scanValues()...)"
+				getRowByKey = "stmt.QueryRowx(s.namedValues()).Scan(s.scanValues()...)"
+
+				g.printJSONValues(name, columns)
+			}
+
+			g.printColumnValues(name, columns)
+			g.printClone(name, columns)
+			g.printString(name, columns)
+
 			g.Printf("func (s *%s) Get(tx *sqlx.Tx, q db.Query, params []interface{}) error {\n", name)
 			g.Printf(`
 			stmt, err := tx.Preparex(string(q))
@@ -409,96 +1369,1375 @@ func (g *Generator) generate(typeName string) {
 				return err
 			}
 
-			if err := stmt.Get(s, params...); err != nil {
+			if err := %s; err != nil {
 				return err
 			}
 
 		return nil
-		}`)
+		}`, getRow)
 			g.Printf("\n")
 			g.Printf("\n")
 
-			g.Printf("func (s *%s) Update(tx *sqlx.Tx) error {\n", name)
+			// selects the row by the key fields of s
+			g.Printf("// GetByKey selects the row with the key of s into s.\n")
+			g.printIncludesDeleted(name, columns)
+			g.Printf("func (s *%s) GetByKey(tx *sqlx.Tx) error {\n", name)
+			g.Printf(`
+			stmt, err := tx.PrepareNamed(string(query%sGetByKey))
+			if err != nil {
+				return err
+			}
+
+			return %s
+		}`, name, getRowByKey)
+			g.Printf("\n")
+			g.Printf("\n")
 
+			// selects the row by each unique column of s
 			for _, column := range columns {
-				// actually check field name (UpdatedAt), instead of
-				// column name
-				if column == "updated_at" {
-					g.Printf("s.UpdatedAt = time.Now()\n")
+				if !column.hasOption("unique") {
+					continue
 				}
+
+				g.Printf("// GetBy%s selects the row with the %s of s into s.\n", g.nameize(column.name), column.name)
+				g.printIncludesDeleted(name, columns)
+				g.Printf("func (s *%s) GetBy%s(tx *sqlx.Tx) error {\n", name, g.nameize(column.name))
+				g.Printf(`
+			stmt, err := tx.PrepareNamed(string(query%sGetBy%s))
+			if err != nil {
+				return err
 			}
 
-			g.Printf(` _, err := tx.NamedExec(string(query%sUpdate), s)
-			return err
-		}
-		`, name)
+			return %s
+		}`, name, g.nameize(column.name), getRowByKey)
+				g.Printf("\n")
+				g.Printf("\n")
+			}
+
+			// checks whether a row with the key fields of s exists,
+			// without fetching it.
+			g.Printf("// Exists reports whether a row with the key of s exists.\n")
+			g.printIncludesDeleted(name, columns)
+			g.Printf("func (s *%s) Exists(tx *sqlx.Tx) (bool, error) {\n", name)
+			g.Printf(`
+			stmt, err := tx.PrepareNamed(string(query%sExists))
+			if err != nil {
+				return false, err
+			}
+
+			var found int
+			if err := stmt.Get(&found, %s); db.IsNoRowsErr(err) {
+				return false, nil
+			} else if err != nil {
+				return false, err
+			}
+
+			return true, nil
+		}`, name, arg)
+			g.Printf("\n")
+			g.Printf("\n")
+
+			g.Printf("func (s *%s) Update(tx *sqlx.Tx) error {\n", name)
+
+			g.printValidate()
+			g.printTimestamps(columns, false)
+
+			if hasVersion {
+				// the update only matches the version that was read, the
+				// row was changed by someone else when nothing matched.
+				g.printExec("query"+name+"Update", arg, "db.ErrStaleObject")
+				g.Printf("s.%s++\n", version.field)
+			} else {
+				g.printExec("query"+name+"Update", arg, "db.ErrNotFound")
+			}
+
+			g.Printf("return nil\n}\n")
+
+			g.printUpdateFields(name, table, keys, writeColumns, arg)
+			g.printUpdateNonZero(name, keys, writeColumns)
 
 			// should we combine update and insert or update?
 			g.Printf("func (s *%s) InsertOrUpdate(tx *sqlx.Tx) error {\n", name)
 
-			for _, column := range columns {
-				// actually check field name (CreatedAt), instead of
-				// column name
-				if column == "created_at" {
-				} else if column == "updated_at" {
-					g.Printf("s.UpdatedAt = time.Now()\n")
-				}
-			}
+			g.printValidate()
+			g.printTimestamps(columns, false)
 
 			g.Printf(`
-			_, err := tx.NamedExec(string(query%sInsertOrUpdate), s)
+			_, err := tx.NamedExec(string(query%sInsertOrUpdate), %s)
 			return err
 		}
-		`, name)
+		`, name, arg)
 
-			g.Printf("func (s *%s) Insert(tx *sqlx.Tx) error {\n", name)
+			// inserts or updates s and reads the stored row back into s,
+			// with the defaults of the database applied.
+			g.Printf("func (s *%s) InsertOrUpdateReturning(tx *sqlx.Tx) error {\n", name)
 
-			for _, column := range columns {
-				// actually check field name (CreatedAt), instead of
-				// column name
-				if column == "created_at" {
-					g.Printf("s.CreatedAt = time.Now()\n")
-				} else if column == "updated_at" {
-					g.Printf("s.UpdatedAt = time.Now()\n")
-				}
+			if g.dialect == dialectPostgres {
+				g.printValidate()
+				g.printTimestamps(columns, false)
+
+				g.Printf(`
+			stmt, err := tx.PrepareNamed(string(query%sInsertOrUpdateReturning))
+			if err != nil {
+				return err
 			}
 
-			g.Printf(`
-			_, err := tx.NamedExec(string(query%sInsert), s)
-			return err
+			return %s
 		}
-		`, name)
+		`, name, getRowByKey)
+			} else {
+				g.Printf(`
+			if err := s.InsertOrUpdate(tx); err != nil {
+				return err
+			}
 
-			g.Printf(`func (s *%s) Delete(tx *sqlx.Tx) error {`, name)
-			g.Printf(`_, err := tx.NamedExec(string(query%sDelete), s)
-			return err
+			return s.GetByKey(tx)
 		}
-		`, name)
+		`)
+			}
 
-			// single (alert) plural (alerts)
-			g.Printf(`func Query%ss() db.Queryx {`, name)
+			g.Printf("func (s *%s) Insert(tx *sqlx.Tx) error {\n", name)
 
-			g.Printf("return db.SelectQuery(\"%s\").\n", *tableName)
-			g.Printf("Fields(\n")
+			g.printValidate()
+			g.printTimestamps(columns, true)
 
-			for name, columns := range file.types {
-				for _, column := range columns {
-					g.Printf("%s%s,\n", name, nameize(column))
-				}
+			switch {
+			case !g.keyAuto:
+				g.Printf(`
+			_, err := tx.NamedExec(string(query%sInsert), %s)
+			return err
+		}
+		`, name, arg)
+			case g.dialect == dialectPostgres:
+				g.Printf(`
+			stmt, err := tx.PrepareNamed(string(query%sInsert))
+			if err != nil {
+				return err
 			}
 
-			g.Printf(")\n")
-			g.Printf("}\n")
-
-			/* g.Printf(`return db.Queryx{
-					Query:  query%sSelect,
-					Params: []interface{}{},
-				}
-			}`, name)
-			*/
-
+			return stmt.Get(&s.%s, %s)
 		}
-	}
+		`, name, autoKey.field, arg)
+			default:
+				g.Printf(`
+			result, err := tx.NamedExec(string(query%sInsert), %s)
+			if err != nil {
+				return err
+			}
+
+			id, err := result.LastInsertId()
+			if err != nil {
+				return err
+			}
+
+			s.%s = %s(id)
+			return nil
+		}
+		`, name, arg, autoKey.field, g.typeString(autoKey.typ))
+			}
+
+			g.Printf("func (s *%s) InsertIgnore(tx *sqlx.Tx) error {\n", name)
+
+			g.printValidate()
+			g.printTimestamps(columns, true)
+
+			g.Printf(`
+			_, err := tx.NamedExec(string(query%sInsertIgnore), %s)
+			return err
+		}
+		`, name, arg)
+
+			// inserts all rows in a single statement, every row is bound
+			// to its own values list.
+			binds := make([]string, len(insertColumns))
+			for i, column := range insertColumns {
+				binds[i] = ":" + column.name
+			}
+
+			g.Printf(`func Insert%ss(tx *sqlx.Tx, rows []%s) error {
+			if len(rows) == 0 {
+				return nil
+			}
+
+			values := make([]string, len(rows))
+			params := []interface{}{}
+
+			for i := range rows {
+				s := &rows[i]
+			`, name, name)
+
+			g.printValidate()
+			g.printTimestamps(columns, true)
+
+			g.Printf(`
+				value, args, err := sqlx.Named("(%s)", %s)
+				if err != nil {
+					return err
+				}
+
+				values[i] = value
+				params = append(params, args...)
+			}
+
+			q := string(query%sInsertMany) + strings.Join(values, ", ")
+
+			_, err := tx.Exec(tx.Rebind(q), params...)
+			return err
+		}
+		`, strings.Join(binds, ", "), arg, name)
+
+			if hasDelete {
+				g.Printf("func (s *%s) Delete(tx *sqlx.Tx) error {\n", name)
+				g.printExec("query"+name+"Delete", arg, "db.ErrNotFound")
+				g.Printf("return nil\n}\n")
+			}
+
+			if hasDelete && len(keys) == 1 {
+				g.Printf(`// Delete%[1]ss deletes the rows with the keys, like Delete. Large lists
+			// are deleted in chunks of db.MaxInParams keys.
+			func Delete%[1]ss(tx *sqlx.Tx, keys []interface{}) error {
+				for len(keys) > 0 {
+					n := len(keys)
+					if n > db.MaxInParams {
+						n = db.MaxInParams
+					}
+
+					q, params, err := sqlx.In(string(query%[1]sDeleteMany), keys[:n])
+					if err != nil {
+						return err
+					}
+
+					if _, err := tx.Exec(tx.Rebind(q), params...); err != nil {
+						return err
+					}
+
+					keys = keys[n:]
+				}
+
+				return nil
+			}
+
+			`, name)
+			}
+
+			g.Printf("func (s *%s) DeleteHard(tx *sqlx.Tx) error {\n", name)
+			g.printExec("query"+name+"DeleteHard", arg, "db.ErrNotFound")
+			g.Printf("return nil\n}\n")
+
+			g.printQuery(name, table, columns)
+			g.printCount(name, table, columns)
+
+			if len(keys) == 1 {
+				scanRow := "rows.StructScan(&s)"
+				if hasJSON(columns) {
+					scanRow = "rows.Scan(s.scanValues()...)"
+				}
+
+				g.Printf(`// Get%[1]ssByKeys selects the rows with the keys, skipping the soft
+			// deleted rows like Query%[1]ss, in no particular order. Large lists are
+			// selected in chunks of db.MaxInParams keys.
+			func Get%[1]ssByKeys(tx *sqlx.Tx, keys []interface{}) ([]%[1]s, error) {
+				result := []%[1]s{}
+				for len(keys) > 0 {
+					n := len(keys)
+					if n > db.MaxInParams {
+						n = db.MaxInParams
+					}
+
+					q, params := Query%[1]ss().Where(db.In(%[2]s, keys[:n])).Build()
+
+					rows, err := tx.Queryx(tx.Rebind(string(q)), params...)
+					if err != nil {
+						return nil, err
+					}
+
+					for rows.Next() {
+						s := %[1]s{}
+						if err := %[3]s; err != nil {
+							rows.Close()
+							return nil, err
+						}
+
+						result = append(result, s)
+					}
+
+					rows.Close()
+
+					if err := rows.Err(); err != nil {
+						return nil, err
+					}
+
+					keys = keys[n:]
+				}
+
+				return result, nil
+			}
+
+			`, name, name+g.nameize(keys[0]), scanRow)
+			}
+
+			/* g.Printf(`return db.Queryx{
+					Query:  query%sSelect,
+					Params: []interface{}{},
+				}
+			}`, name)
+			*/
+
+		}
+	}
+}
+
+// printQuery prints the Query<Type>s function, selecting the columns of the
+// type.
+func (g *Generator) printQuery(name, table string, columns []Column) {
+	// single (alert) plural (alerts)
+	g.Printf(`func Query%ss() db.Queryx {`, name)
+
+	g.Printf("return db.SelectQuery(\"%s\").\n", g.quoteTable(table))
+	g.Printf("Fields(\n")
+
+	for _, column := range columns {
+		g.Printf("%s%s,\n", name, g.nameize(column.name))
+	}
+
+	g.Printf(")")
+	g.printSoftDelete(name, columns)
+	g.Printf("\n}\n")
+}
+
+// printCount prints the Count<Type>s function, counting the rows
+// Query<Type>s selects, for use with tx.Countx.
+func (g *Generator) printCount(name, table string, columns []Column) {
+	g.Printf(`func Count%ss() db.Queryx {`, name)
+
+	g.Printf("return db.SelectQuery(\"%s\").\n", g.quoteTable(table))
+	g.Printf("Fields(\"COUNT(*)\")")
+	g.printSoftDelete(name, columns)
+	g.Printf("\n}\n")
+	g.printGroupCounts(name, table, columns)
+}
+
+// printGroupCounts prints the <Type><Column>Count struct and the
+// Query<Type>CountBy<Column> function, counting the rows by the value of the
+// column, for every column tagged as db:"<column>,groupable".
+func (g *Generator) printGroupCounts(name, table string, columns []Column) {
+	for _, column := range columns {
+		if !column.hasOption("groupable") {
+			continue
+		}
+
+		field := g.nameize(column.name)
+		if field == "Count" {
+			log.Fatalf("error: groupable column %q of type %s is named like the count", column.name, name)
+		}
+
+		g.Printf("// %s%sCount holds the number of rows of %s by %s.\n", name, field, table, column.name)
+		g.Printf("type %s%sCount struct {\n", name, field)
+		g.Printf("%s %s `%s:\"%s\"`\n", field, g.typeString(column.typ), g.tagName, column.name)
+		g.Printf("Count int `%s:\"count\"`\n", g.tagName)
+		g.Printf("}\n\n")
+
+		g.Printf("// Query%sCountBy%s selects the number of rows by %s, into a\n", name, field, column.name)
+		g.Printf("// []%s%sCount.\n", name, field)
+		g.Printf("func Query%sCountBy%s() db.Queryx {\n", name, field)
+		g.Printf("return db.SelectQuery(\"%s\").\n", g.quoteTable(table))
+		g.Printf("Fields(%s%s, db.As(db.Count(\"*\"), \"count\")).\n", name, field)
+		g.Printf("GroupBy(%s%s)", name, field)
+		g.printSoftDelete(name, columns)
+		g.Printf("\n}\n\n")
+	}
+}
+
+// whereKeys returns the condition matching a single row on its key columns.
+func (g *Generator) whereKeys(keys []string) string {
+	conds := make([]string, len(keys))
+	for i, key := range keys {
+		conds[i] = fmt.Sprintf("%s=:%s", g.quote(key), key)
+	}
+
+	return strings.Join(conds, " AND ")
+}
+
+// printExec executes the named query bound to arg, it returns errNotFound
+// when no row was affected.
+func (g *Generator) printExec(query, arg, errNotFound string) {
+	g.Printf(`result, err := tx.NamedExec(string(%s), %s)
+	if err != nil {
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return %s
+	}
+
+	`, query, arg, errNotFound)
+}
+
+// printUpdateFields prints the UpdateFields method, updating only the
+// requested columns. Columns holding the updated timestamp are always set.
+func (g *Generator) printUpdateFields(typeName, table string, keys []string, columns []Column, arg string) {
+	g.Printf("// update%sColumns holds the quoted names of the columns of %s.\n", typeName, typeName)
+	g.Printf("var update%sColumns = map[string]string{\n", typeName)
+	for _, column := range columns {
+		g.Printf("%q: \"%s\",\n", column.name, g.quote(column.name))
+	}
+	g.Printf("}\n\n")
+
+	updated := []string{}
+	for _, column := range columns {
+		if g.isUpdated(column) {
+			updated = append(updated, fmt.Sprintf("%q", column.name))
+		}
+	}
+
+	g.Printf(`// UpdateFields updates the columns of the row with the key fields of s,
+	// other columns are left unchanged.
+	func (s *%s) UpdateFields(tx *sqlx.Tx, columns ...string) error {
+	`, typeName)
+
+	g.printTimestamps(columns, false)
+
+	if len(updated) > 0 {
+		g.Printf(`
+		for _, column := range []string{%s} {
+			found := false
+			for _, c := range columns {
+				found = found || c == column
+			}
+
+			if !found {
+				columns = append(columns, column)
+			}
+		}
+		`, strings.Join(updated, ", "))
+	}
+
+	version, hasVersion := findColumn(columns, g.versionColumn)
+	if !hasVersion {
+		g.Printf(`
+	if len(columns) == 0 {
+		return nil
+	}
+
+	set := make([]string, len(columns))
+	for i, column := range columns {
+		quoted, ok := update%sColumns[column]
+		if !ok {
+			return fmt.Errorf("unknown column %%q of %s", column)
+		}
+
+		set[i] = quoted + "=:" + column
+	}
+
+	q := "UPDATE %s SET " + strings.Join(set, ", ") + " WHERE %s"
+
+	`, typeName, typeName, g.quoteTable(table), g.whereKeys(keys))
+
+		g.printExec("q", arg, "db.ErrNotFound")
+		g.Printf("return nil\n}\n")
+		return
+	}
+
+	// like Update, the version that was read is matched and incremented,
+	// it can't be set.
+	g.Printf(`
+	if len(columns) == 0 {
+		return nil
+	}
+
+	set := make([]string, 0, len(columns)+1)
+	for _, column := range columns {
+		quoted, ok := update%sColumns[column]
+		if !ok {
+			return fmt.Errorf("unknown column %%q of %s", column)
+		}
+
+		if column != %q {
+			set = append(set, quoted+"=:"+column)
+		}
+	}
+
+	set = append(set, "%s=%s+1")
+
+	q := "UPDATE %s SET " + strings.Join(set, ", ") + " WHERE %s AND %s=:%s"
+
+	`, typeName, typeName, version.name, g.quote(version.name), g.quote(version.name), g.quoteTable(table), g.whereKeys(keys), g.quote(version.name), version.name)
+
+	g.printExec("q", arg, "db.ErrStaleObject")
+	g.Printf("s.%s++\n", version.field)
+	g.Printf("return nil\n}\n")
+}
+
+// printUpdateNonZero prints the UpdateNonZero method, updating the columns
+// of the fields that don't hold their zero value through UpdateFields.
+func (g *Generator) printUpdateNonZero(typeName string, keys []string, columns []Column) {
+	g.Printf(`// UpdateNonZero updates the columns of the fields of s that don't hold
+	// their zero value, like UpdateFields, eg. for a partial update. Columns
+	// can't be set to their zero value this way, use Update or UpdateFields.
+	func (s *%s) UpdateNonZero(tx *sqlx.Tx) error {
+	`, typeName)
+
+	// like Update, the version is always matched and incremented, by
+	// UpdateFields
+	version, hasVersion := findColumn(columns, g.versionColumn)
+	if hasVersion {
+		g.Printf("columns := []string{%q}\n", version.name)
+	} else {
+		g.Printf("columns := []string{}\n")
+	}
+
+	for _, column := range columns {
+		if contains(keys, column.name) || (hasVersion && column.name == version.name) {
+			continue
+		}
+
+		// fields of nil embedded pointers are not set
+		conds := append(column.notNil(), fmt.Sprintf("!db.IsZero(s.%s)", column.field))
+
+		g.Printf("if %s {\n", strings.Join(conds, " && "))
+		g.Printf("columns = append(columns, %q)\n", column.name)
+		g.Printf("}\n\n")
+	}
+
+	g.Printf("return s.UpdateFields(tx, columns...)\n}\n")
+}
+
+// printJSONValues prints the namedValues, scanValues and ScanRow methods,
+// binding and scanning the fields of JSON columns through db.JSON.
+func (g *Generator) printJSONValues(typeName string, columns []Column) {
+	g.Printf("// namedValues returns the named parameters of the columns of s.\n")
+	g.Printf("func (s *%s) namedValues() map[string]interface{} {\n", typeName)
+	g.printValueMap(columns, func(column Column) string {
+		if column.hasOption("json") {
+			return fmt.Sprintf("db.JSON{V: &s.%s}", column.field)
+		}
+
+		return "s." + column.field
+	})
+	g.Printf("}\n\n")
+
+	g.Printf("// scanValues returns the scan destinations of the columns of s, in\n")
+	g.Printf("// the order of query%sSelect.\n", typeName)
+	g.Printf("func (s *%s) scanValues() []interface{} {\n", typeName)
+	g.printNewPointers(columns)
+	g.Printf("return []interface{}{\n")
+	for _, column := range columns {
+		if column.hasOption("json") {
+			g.Printf("&db.JSON{V: &s.%s},\n", column.field)
+		} else {
+			g.Printf("&s.%s,\n", column.field)
+		}
+	}
+	g.Printf("}\n}\n\n")
+
+	g.printScanRow(typeName, columns)
+}
+
+// printScanRow prints the ScanRow method, scanning the columns of the row
+// by name so db.Tx.Selectx can select slices of types with JSON columns,
+// which sqlx can't scan.
+func (g *Generator) printScanRow(typeName string, columns []Column) {
+	g.usesFmt = true
+
+	g.Printf("// ScanRow scans the current row of rows into s by column name, Selectx\n")
+	g.Printf("// uses it to select a []%s with JSON columns.\n", typeName)
+	g.Printf("func (s *%s) ScanRow(rows *sqlx.Rows) error {\n", typeName)
+	g.printNewPointers(columns)
+	g.Printf("dests := map[string]interface{}{\n")
+	for _, column := range columns {
+		if column.hasOption("json") {
+			g.Printf("%q: &db.JSON{V: &s.%s},\n", column.name, column.field)
+		} else {
+			g.Printf("%q: &s.%s,\n", column.name, column.field)
+		}
+	}
+	g.Printf("}\n\n")
+
+	g.Printf(`columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		dest, ok := dests[column]
+		if !ok {
+			return fmt.Errorf("missing destination of column %%q in *%s", column)
+		}
+
+		values[i] = dest
+	}
+
+	return rows.Scan(values...)
+}
+
+`, typeName)
+}
+
+// printNewPointers allocates the nil embedded pointers of s, like sqlx does
+// to scan into their fields. Outer pointers are allocated first.
+func (g *Generator) printNewPointers(columns []Column) {
+	allocated := map[string]bool{}
+	for _, column := range columns {
+		for i, pointer := range column.pointers {
+			if allocated[pointer] {
+				continue
+			}
+
+			allocated[pointer] = true
+			g.Printf("if s.%s == nil {\n", pointer)
+			g.Printf("s.%s = new(%s)\n", pointer, g.typeString(column.pointerElems[i]))
+			g.Printf("}\n\n")
+		}
+	}
+}
+
+// printColumnValues prints the ColumnValues method, returning the values of
+// the fields by column name, eg. to compare states for an audit trail.
+func (g *Generator) printColumnValues(typeName string, columns []Column) {
+	g.Printf("// ColumnValues returns the values of the columns of s by column name.\n")
+	g.Printf("func (s *%s) ColumnValues() map[string]interface{} {\n", typeName)
+	g.printValueMap(columns, func(column Column) string {
+		return "s." + column.field
+	})
+	g.Printf("}\n\n")
+}
+
+// printValueMap prints the return of a map of the values of the columns by
+// column name. The columns of nil embedded pointers are nil.
+func (g *Generator) printValueMap(columns []Column, value func(Column) string) {
+	guarded := false
+	for _, column := range columns {
+		if len(column.pointers) > 0 {
+			guarded = true
+		}
+	}
+
+	if !guarded {
+		g.Printf("return map[string]interface{}{\n")
+		for _, column := range columns {
+			g.Printf("%q: %s,\n", column.name, value(column))
+		}
+		g.Printf("}\n")
+		return
+	}
+
+	g.Printf("values := map[string]interface{}{\n")
+	for _, column := range columns {
+		if len(column.pointers) > 0 {
+			g.Printf("%q: nil,\n", column.name)
+		} else {
+			g.Printf("%q: %s,\n", column.name, value(column))
+		}
+	}
+	g.Printf("}\n\n")
+
+	for _, column := range columns {
+		if len(column.pointers) == 0 {
+			continue
+		}
+
+		g.Printf("if %s {\n", strings.Join(column.notNil(), " && "))
+		g.Printf("values[%q] = %s\n", column.name, value(column))
+		g.Printf("}\n\n")
+	}
+
+	g.Printf("return values\n")
+}
+
+// printString prints the String method listing the columns of s with their
+// values, eg. Alert{id=1 name="disk"}, with -stringer.
+func (g *Generator) printString(typeName string, columns []Column) {
+	if !g.stringer {
+		return
+	}
+
+	g.usesFmt = true
+
+	g.Printf("// String returns the columns of s with their values, for debug logging.\n")
+	g.Printf("func (s *%s) String() string {\n", typeName)
+	g.Printf("b := strings.Builder{}\n")
+	g.Printf("b.WriteString(%q)\n", typeName+"{")
+
+	for i, column := range columns {
+		verb := "%v"
+		if basic, ok := column.typ.Underlying().(*types.Basic); ok && basic.Info()&types.IsString != 0 {
+			verb = "%q"
+		}
+
+		format := column.name + "=" + verb
+		if i > 0 {
+			format = " " + format
+		}
+
+		// fields of nil embedded pointers are left out
+		conds := column.notNil()
+		if len(conds) > 0 {
+			g.Printf("if %s {\n", strings.Join(conds, " && "))
+		}
+
+		g.Printf("fmt.Fprintf(&b, %q, s.%s)\n", format, column.field)
+
+		if len(conds) > 0 {
+			g.Printf("}\n")
+		}
+	}
+
+	g.Printf("b.WriteString(\"}\")\n")
+	g.Printf("return b.String()\n}\n\n")
+}
+
+// printEnums prints the names of the constants of the named integer types
+// of the columns declared in the package, eg. StatusNames for a Status
+// column, to validate or serialize the values. Each type is printed once.
+func (g *Generator) printEnums(columns []Column) {
+	for _, column := range columns {
+		named, ok := column.typ.(*types.Named)
+		if !ok || g.enums[named.Obj()] {
+			continue
+		}
+
+		values := g.enumValues(named)
+		if len(values) == 0 {
+			continue
+		}
+
+		if g.enums == nil {
+			g.enums = map[*types.TypeName]bool{}
+		}
+
+		g.enums[named.Obj()] = true
+
+		typeName := named.Obj().Name()
+		g.Printf("// %sNames holds the names of the %s values.\n", typeName, typeName)
+		g.Printf("var %sNames = map[%s]string{\n", typeName, typeName)
+		for _, value := range values {
+			g.Printf("%s: %q,\n", value.originalName, value.name)
+		}
+		g.Printf("}\n\n")
+	}
+}
+
+// enumValues returns the constants of the named integer type declared in
+// the package, in increasing order. Of constants with the same value only
+// the first is returned.
+func (g *Generator) enumValues(named *types.Named) []Value {
+	basic, ok := named.Underlying().(*types.Basic)
+	if !ok || basic.Info()&types.IsInteger == 0 || named.Obj().Pkg() == nil || named.Obj().Pkg().Name() != g.pkg.name {
+		return nil
+	}
+
+	values := []Value{}
+	for _, file := range g.pkg.files {
+		if file.file == nil {
+			continue
+		}
+
+		for _, decl := range file.file.Decls {
+			decl, ok := decl.(*ast.GenDecl)
+			if !ok || decl.Tok != token.CONST {
+				continue
+			}
+
+			for _, spec := range decl.Specs {
+				vspec := spec.(*ast.ValueSpec)
+				for _, ident := range vspec.Names {
+					if ident.Name == "_" {
+						continue
+					}
+
+					obj, ok := g.pkg.defs[ident].(*types.Const)
+					if !ok || !types.Identical(obj.Type(), named) {
+						continue
+					}
+
+					value := Value{
+						originalName: ident.Name,
+						name:         strings.TrimSuffix(strings.TrimPrefix(ident.Name, g.trimPrefix), g.trimSuffix),
+						signed:       basic.Info()&types.IsUnsigned == 0,
+						str:          obj.Val().String(),
+					}
+
+					if c := vspec.Comment; g.lineComment && c != nil && len(c.List) == 1 {
+						value.name = strings.TrimSpace(c.Text())
+					}
+
+					i64, isInt := constant.Int64Val(obj.Val())
+					u64, isUint := constant.Uint64Val(obj.Val())
+					if !isInt && !isUint {
+						continue
+					}
+
+					value.value = u64
+					if !isUint {
+						value.value = uint64(i64)
+					}
+
+					values = append(values, value)
+				}
+			}
+		}
+	}
+
+	sort.Stable(byValue(values))
+
+	// the map of the names can hold a value once
+	unique := values[:0]
+	for _, value := range values {
+		if len(unique) > 0 && value.value == unique[len(unique)-1].value {
+			continue
+		}
+
+		unique = append(unique, value)
+	}
+
+	return unique
+}
+
+// printClone prints the Clone method, copying the fields of the columns.
+// Slices, maps and pointers are copied one level deep, so changing the copy
+// doesn't change s, eg. when diffing the ColumnValues of both.
+func (g *Generator) printClone(typeName string, columns []Column) {
+	g.Printf("// Clone returns a copy of the columns of s.\n")
+	g.Printf("func (s *%s) Clone() *%s {\n", typeName, typeName)
+	g.Printf("c := &%s{}\n", typeName)
+
+	// the embedded structs of pointers are copied before their fields are.
+	copied := map[string]bool{}
+	for _, column := range columns {
+		for _, pointer := range column.pointers {
+			if copied[pointer] {
+				continue
+			}
+
+			copied[pointer] = true
+			g.Printf("if s.%s != nil {\n", pointer)
+			g.Printf("v := *s.%s\n", pointer)
+			g.Printf("c.%s = &v\n", pointer)
+			g.Printf("}\n")
+		}
+	}
+
+	for _, column := range columns {
+		// the copy of the embedded struct holds the other fields, the
+		// fields of nil embedded pointers stay nil in the copy
+		conds := column.notNil()
+		switch column.typ.Underlying().(type) {
+		case *types.Slice, *types.Map, *types.Pointer:
+		default:
+			if len(conds) > 0 {
+				continue
+			}
+		}
+
+		if len(conds) > 0 {
+			g.Printf("if %s {\n", strings.Join(conds, " && "))
+		}
+
+		switch column.typ.Underlying().(type) {
+		case *types.Slice:
+			g.Printf("c.%s = append(s.%s[:0:0], s.%s...)\n", column.field, column.field, column.field)
+		case *types.Map:
+			g.Printf("if s.%s != nil {\n", column.field)
+			g.Printf("c.%s = make(%s, len(s.%s))\n", column.field, g.typeString(column.typ), column.field)
+			g.Printf("for k, v := range s.%s {\n", column.field)
+			g.Printf("c.%s[k] = v\n", column.field)
+			g.Printf("}\n}\n")
+		case *types.Pointer:
+			g.Printf("if s.%s != nil {\n", column.field)
+			g.Printf("v := *s.%s\n", column.field)
+			g.Printf("c.%s = &v\n", column.field)
+			g.Printf("}\n")
+		default:
+			g.Printf("c.%s = s.%s\n", column.field, column.field)
+		}
+
+		if len(conds) > 0 {
+			g.Printf("}\n")
+		}
+	}
+	g.Printf("return c\n")
+	g.Printf("}\n\n")
+}
+
+// hasJSON reports whether any of the columns is tagged as JSON column.
+func hasJSON(columns []Column) bool {
+	for _, c := range columns {
+		if c.hasOption("json") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// printValidate returns the error of Validate, when s implements
+// db.Validator. The assertion is on an interface, so types without a
+// Validate method compile.
+func (g *Generator) printValidate() {
+	g.Printf(`if v, ok := interface{}(s).(db.Validator); ok {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+	}
+
+	`)
+}
+
+// printTimestamps sets the timestamp fields of s to the current time in
+// UTC, or the local time with -local-time. The created timestamp is only
+// set on insert.
+func (g *Generator) printTimestamps(columns []Column, insert bool) {
+	now := "time.Now().UTC()"
+	if g.localTime {
+		now = "time.Now()"
+	}
+
+	for _, column := range columns {
+		if (insert && g.isCreated(column)) || g.isUpdated(column) {
+			g.usesTime = true
+			g.Printf("s.%s = %s\n", column.field, now)
+		}
+	}
+}
+
+// isCreated reports whether the column holds the time a row was inserted.
+func (g *Generator) isCreated(c Column) bool {
+	return c.isTime() && (c.hasOption("created") || c.name == g.createdColumn)
+}
+
+// isUpdated reports whether the column holds the time a row was last
+// inserted or updated.
+func (g *Generator) isUpdated(c Column) bool {
+	return c.isTime() && (c.hasOption("updated") || c.name == g.updatedColumn)
+}
+
+// canDelete reports whether a valid Delete can be generated for the columns,
+// a soft delete needs its column in the struct.
+func (g *Generator) canDelete(columns []Column) bool {
+	return g.softDeleteColumn == "" || hasColumn(columns, g.softDeleteColumn)
+}
+
+// printIncludesDeleted notes in the doc comment of a method selecting by
+// key that it doesn't filter the soft deleted rows, eg. to restore them.
+func (g *Generator) printIncludesDeleted(name string, columns []Column) {
+	if g.softDeleteColumn == "" || !hasColumn(columns, g.softDeleteColumn) {
+		return
+	}
+
+	g.Printf("// Unlike Query%ss, it includes the soft deleted rows.\n", name)
+}
+
+// printSoftDelete filters the soft deleted rows out of the query. A column
+// soft deleted to 0 or FALSE has to be true, eg. active, a column soft
+// deleted to 1 or TRUE has to be false, eg. is_deleted, other columns have
+// to be NULL, eg. deleted_at.
+func (g *Generator) printSoftDelete(name string, columns []Column) {
+	if g.softDeleteColumn == "" || !hasColumn(columns, g.softDeleteColumn) {
+		return
+	}
+
+	switch strings.ToUpper(g.softDeleteValue) {
+	case "0", "FALSE":
+		g.Printf(".\nSoftDelete(db.True(%s%s))", name, g.nameize(g.softDeleteColumn))
+	case "1", "TRUE":
+		g.Printf(".\nSoftDelete(db.False(%s%s))", name, g.nameize(g.softDeleteColumn))
+	default:
+		g.Printf(".\nSoftDelete(db.IsNull(%s%s))", name, g.nameize(g.softDeleteColumn))
+	}
+}
+
+// generateTest produces a round-trip test of the generated methods of the
+// named type, it runs the queries against sqlmock. Binding the queries to
+// the struct catches columns without a matching field.
+func (g *Generator) generateTest(typeName string) {
+	for _, file := range g.pkg.files {
+		columns, ok := file.types[typeName]
+		if !ok || file.views[typeName] {
+			continue
+		}
+
+		g.writes = true
+
+		// types with JSON columns are bound from a map
+		arg := "s"
+		if hasJSON(columns) {
+			arg = "s.namedValues()"
+		}
+
+		names := make([]string, len(columns))
+		binds := make([]string, len(columns))
+		for i, column := range columns {
+			names[i] = fmt.Sprintf("%q", column.name)
+			binds[i] = ":" + column.name
+		}
+
+		keys := g.keys(columns)
+
+		// with -key-auto the inserts leave out the key, postgres reads it
+		// from the inserted row
+		writeColumns := writableColumns(columns)
+
+		insertBinds := []string{}
+		for _, column := range writeColumns {
+			insertBinds = append(insertBinds, ":"+column.name)
+		}
+
+		expectInsert := fmt.Sprintf("expectExec(string(query%sInsert))", typeName)
+		if g.keyAuto {
+			insertBinds = []string{}
+			for _, column := range writeColumns {
+				if column.name != keys[0] {
+					insertBinds = append(insertBinds, ":"+column.name)
+				}
+			}
+
+			if g.dialect == dialectPostgres {
+				expectInsert = fmt.Sprintf(`insert, _ := bind(string(query%sInsert))
+			mock.ExpectPrepare(insert).ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{%q}).AddRow(1))`, typeName, keys[0])
+			}
+		}
+
+		g.Printf(`func Test%[1]sGenerated(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer conn.Close()
+
+			mock.ExpectBegin()
+
+			tx, err := sqlx.NewDb(conn, "sqlmock").Beginx()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			s := &%[1]s{}
+
+			// the embedded pointers of s are nil
+			if c := s.Clone(); c == nil {
+				t.Fatalf("Clone: got nil")
+			}
+
+			if values := s.ColumnValues(); len(values) != %[6]d {
+				t.Fatalf("ColumnValues: got %%d columns, want %[6]d", len(values))
+			}
+
+		`, typeName, strings.Join(binds, ", "), strings.Join(names, ", "), arg, expectInsert, len(columns))
+
+		// sqlx can't bind the fields of nil embedded pointers
+		g.printNewPointers(columns)
+
+		// sqlx can't scan JSON columns, Selectx scans the slice through
+		// the generated ScanRow
+		selectSlice := ""
+		if hasJSON(columns) {
+			selectSlice = fmt.Sprintf(`
+			q, _ := Query%[1]ss().Build()
+
+			mock.ExpectPrepare(regexp.QuoteMeta(string(q))).ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{%[2]s}).AddRow(values...))
+
+			selected := []%[1]s{}
+			if err := (&db.Tx{Tx: tx}).Selectx(&selected, Query%[1]ss()); err != nil {
+				t.Fatalf("Selectx: %%s", err)
+			} else if len(selected) != 1 {
+				t.Fatalf("Selectx: got %%d rows, want 1", len(selected))
+			}
+			`, typeName, strings.Join(names, ", "))
+		}
+
+		g.Printf(`
+
+			// bind the named query to s, this fails for columns
+			// without a matching field.
+			bind := func(q string) (string, []driver.Value) {
+				query, args, err := sqlx.Named(q, %[4]s)
+				if err != nil {
+					t.Fatalf("binding %%s: %%s", q, err)
+				}
+
+				values := make([]driver.Value, len(args))
+				for i, arg := range args {
+					if v, ok := arg.(driver.Valuer); ok {
+						arg, _ = v.Value()
+					}
+
+					values[i] = arg
+				}
+
+				return regexp.QuoteMeta(query), values
+			}
+
+			// the values change while executing (eg. updated_at), we
+			// only check the number of arguments.
+			expectExec := func(q string) {
+				query, values := bind(q)
+				for i := range values {
+					values[i] = sqlmock.AnyArg()
+				}
+
+				mock.ExpectExec(query).WithArgs(values...).WillReturnResult(sqlmock.NewResult(1, 1))
+			}
+
+			%[5]s
+			if err := s.Insert(tx); err != nil {
+				t.Fatalf("Insert: %%s", err)
+			}
+
+			_, values := bind(%[2]q)
+
+			rows := sqlmock.NewRows([]string{%[3]s}).AddRow(values...)
+			mock.ExpectPrepare(regexp.QuoteMeta(string(query%[1]sSelect))).ExpectQuery().WillReturnRows(rows)
+			if err := s.Get(tx, query%[1]sSelect, nil); err != nil {
+				t.Fatalf("Get: %%s", err)
+			}
+			%[7]s
+			query, _ := bind(string(query%[1]sGetByKey))
+
+			rows = sqlmock.NewRows([]string{%[3]s}).AddRow(values...)
+			mock.ExpectPrepare(query).ExpectQuery().WillReturnRows(rows)
+			if err := s.GetByKey(tx); err != nil {
+				t.Fatalf("GetByKey: %%s", err)
+			}
+
+			query, _ = bind(string(query%[1]sExists))
+
+			mock.ExpectPrepare(query).ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+			if exists, err := s.Exists(tx); err != nil {
+				t.Fatalf("Exists: %%s", err)
+			} else if !exists {
+				t.Fatalf("Exists: got false, want true")
+			}
+
+			mock.ExpectPrepare(query).ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"1"}))
+			if exists, err := s.Exists(tx); err != nil {
+				t.Fatalf("Exists: %%s", err)
+			} else if exists {
+				t.Fatalf("Exists: got true, want false")
+			}
+
+			expectExec(string(query%[1]sUpdate))
+			if err := s.Update(tx); err != nil {
+				t.Fatalf("Update: %%s", err)
+			}
+		`, typeName, strings.Join(binds, ", "), strings.Join(names, ", "), arg, expectInsert, len(columns), selectSlice)
+
+		errNotFound := "db.ErrNotFound"
+		if _, ok := findColumn(columns, g.versionColumn); ok {
+			errNotFound = "db.ErrStaleObject"
+		}
+
+		g.Printf(`
+			query, _ = bind(string(query%[1]sUpdate))
+
+			mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(0, 0))
+			if err := s.Update(tx); err != %[2]s {
+				t.Fatalf("Update: got %%v, want %%v", err, %[2]s)
+			}
+			`, typeName, errNotFound)
+
+		g.Printf(`
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE ")).WillReturnResult(sqlmock.NewResult(1, 1))
+			if err := s.UpdateFields(tx, %[2]q); err != nil {
+				t.Fatalf("UpdateFields: %%s", err)
+			}
+
+			if err := s.UpdateFields(tx, "-"); err == nil {
+				t.Fatalf("UpdateFields: got no error for an unknown column")
+			}
+			`, typeName, writeColumns[len(writeColumns)-1].name)
+
+		g.Printf(`
+			expectExec(string(query%[1]sInsertIgnore))
+			if err := s.InsertIgnore(tx); err != nil {
+				t.Fatalf("InsertIgnore: %%s", err)
+			}
+			`, typeName)
+
+		if g.canDelete(columns) {
+			g.Printf(`
+			expectExec(string(query%[1]sDelete))
+			if err := s.Delete(tx); err != nil {
+				t.Fatalf("Delete: %%s", err)
+			}
+			`, typeName)
+		}
+
+		if g.canDelete(columns) && len(keys) == 1 {
+			g.Printf(`
+			mock.ExpectExec(regexp.QuoteMeta(" IN (?, ?)")).WillReturnResult(sqlmock.NewResult(0, 2))
+			if err := Delete%[1]ss(tx, []interface{}{1, 2}); err != nil {
+				t.Fatalf("Delete%[1]ss: %%s", err)
+			}
+
+			if err := Delete%[1]ss(tx, nil); err != nil {
+				t.Fatalf("Delete%[1]ss: %%s", err)
+			}
+			`, typeName)
+		}
+
+		if len(keys) == 1 {
+			g.Printf(`
+			rows = sqlmock.NewRows([]string{%[2]s}).AddRow(values...)
+			mock.ExpectQuery(regexp.QuoteMeta(" IN (")).WillReturnRows(rows)
+			if got, err := Get%[1]ssByKeys(tx, []interface{}{1}); err != nil {
+				t.Fatalf("Get%[1]ssByKeys: %%s", err)
+			} else if len(got) != 1 {
+				t.Fatalf("Get%[1]ssByKeys: got %%d rows, want 1", len(got))
+			}
+			`, typeName, strings.Join(names, ", "))
+		}
+
+		g.Printf(`
+			expectExec(string(query%[1]sDeleteHard))
+			if err := s.DeleteHard(tx); err != nil {
+				t.Fatalf("DeleteHard: %%s", err)
+			}
+
+			value, rowValues := bind("(%[2]s)")
+
+			args := make([]driver.Value, 2*len(rowValues))
+			for i := range args {
+				args[i] = sqlmock.AnyArg()
+			}
+
+			mock.ExpectExec(regexp.QuoteMeta(string(query%[1]sInsertMany)) + value + ", " + value).WithArgs(args...).WillReturnResult(sqlmock.NewResult(2, 2))
+			if err := Insert%[1]ss(tx, []%[1]s{*s, *s}); err != nil {
+				t.Fatalf("Insert%[1]ss: %%s", err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		}
+		`, typeName, strings.Join(insertBinds, ", "))
+	}
+}
+
+// hasColumn reports whether column is one of columns.
+func hasColumn(columns []Column, column string) bool {
+	for _, c := range columns {
+		if c.name == column {
+			return true
+		}
+	}
+
+	return false
+}
+
+// contains reports whether the list contains s.
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writableColumns returns the columns without the readonly columns, tagged
+// as db:"<column>,readonly".
+func writableColumns(columns []Column) []Column {
+	writable := []Column{}
+	for _, c := range columns {
+		if !c.hasOption("readonly") {
+			writable = append(writable, c)
+		}
+	}
+
+	return writable
+}
+
+// findColumn returns the column with the name from columns.
+func findColumn(columns []Column, name string) (Column, bool) {
+	for _, c := range columns {
+		if c.name == name {
+			return c, true
+		}
+	}
+
+	return Column{}, false
+}
+
+// autoKey returns the key column assigned by the database and the columns
+// without it, the key must be a single integer column.
+func (g *Generator) autoKey(name string, columns []Column, keys []string) (Column, []Column) {
+	if len(keys) != 1 {
+		log.Fatalf("error: -key-auto needs a single key column, type %s has %d", name, len(keys))
+	}
+
+	key, ok := findColumn(columns, keys[0])
+	if !ok {
+		log.Fatalf("error: key column %q not found in type %s", keys[0], name)
+	}
+
+	if !key.isInteger() {
+		log.Fatalf("error: key column %q of type %s is not an integer", key.name, name)
+	}
+
+	rest := make([]Column, 0, len(columns)-1)
+	for _, c := range columns {
+		if c.name != key.name {
+			rest = append(rest, c)
+		}
+	}
+
+	return key, rest
+}
+
+// typeString returns the type as written in the generated package.
+func (g *Generator) typeString(typ types.Type) string {
+	return types.TypeString(typ, func(pkg *types.Package) string {
+		if pkg.Name() == g.pkg.name {
+			return ""
+		}
+
+		return pkg.Name()
+	})
+}
+
+// columnNames returns the names of the columns.
+func columnNames(columns []Column) []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.name
+	}
+
+	return names
+}
+
+// keyColumns returns the names of the columns tagged as primary key.
+func keyColumns(columns []Column) []string {
+	keys := []string{}
+	for _, c := range columns {
+		if c.key {
+			keys = append(keys, c.name)
+		}
+	}
+
+	return keys
+}
+
+// keys returns the key columns of the type: the tagged key columns, else
+// the columns of -key, else the id column when the type has one.
+func (g *Generator) keys(columns []Column) []string {
+	if keys := keyColumns(columns); len(keys) > 0 {
+		return keys
+	}
+
+	if len(g.tableKeys) > 0 {
+		return g.tableKeys
+	}
+
+	if hasColumn(columns, "id") {
+		return []string{"id"}
+	}
+
+	return nil
 }
 
 // format returns the gofmt-ed contents of the Generator's buffer.
@@ -511,14 +2750,64 @@ func (g *Generator) format() []byte {
 		log.Printf("warning: compile the package to analyze the error")
 		return g.buf.Bytes()
 	}
+
+	if g.dbAlias != "" && g.dbAlias != "db" {
+		src, err = renameImport(src, "db", g.dbAlias)
+		if err != nil {
+			log.Fatalf("error: renaming the db import: %s", err)
+		}
+	}
+
 	return src
 }
 
+// renameImport renames the import named from in the source to the name to,
+// including the references to the package.
+func renameImport(src []byte, from, to string) ([]byte, error) {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, spec := range file.Imports {
+		if spec.Name != nil && spec.Name.Name == from {
+			spec.Name.Name = to
+		}
+	}
+
+	// references to the package are selectors on an identifier that
+	// isn't declared in the file.
+	ast.Inspect(file, func(node ast.Node) bool {
+		if sel, ok := node.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == from && ident.Obj == nil {
+				ident.Name = to
+			}
+		}
+
+		return true
+	})
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
 // Run goimports to format and update imports statements in generated code.
 func goimports(filename string, inputBytes []byte) (outputBytes []byte, err error) {
-	if false {
+	if !*useImports {
 		return inputBytes, nil
 	}
+
+	if _, err := exec.LookPath("goimports"); err != nil {
+		log.Printf("warning: goimports not found, the imports of the generated code are not updated")
+		return inputBytes, nil
+	}
+
 	cmd := exec.Command("goimports")
 	// cmd := exec.Command(os.Getenv("GOPATH") + "/bin/goimports")
 	input, _ := cmd.StdinPipe()
@@ -0,0 +1,1478 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type NameizeSet struct {
+	TrimPrefix string
+	TrimSuffix string
+	Name       string
+	Want       string
+}
+
+var (
+	TestSetNameize = []NameizeSet{
+		{
+			Name: "alert_status",
+			Want: "AlertStatus",
+		},
+		{
+			TrimPrefix: "alert_",
+			Name:       "alert_status",
+			Want:       "Status",
+		},
+		{
+			TrimPrefix: "alert_",
+			Name:       "user_id",
+			Want:       "UserID",
+		},
+		{
+			TrimPrefix: "alert_",
+			Name:       "alert_",
+			Want:       "Alert",
+		},
+		{
+			TrimSuffix: "_code",
+			Name:       "status_code",
+			Want:       "Status",
+		},
+		{
+			TrimSuffix: "_code",
+			Name:       "status",
+			Want:       "Status",
+		},
+		{
+			TrimSuffix: "_code",
+			Name:       "_code",
+			Want:       "Code",
+		},
+		{
+			TrimPrefix: "alert_",
+			TrimSuffix: "_code",
+			Name:       "alert_error_code",
+			Want:       "Error",
+		},
+	}
+)
+
+func TestNameize(t *testing.T) {
+	for _, ts := range TestSetNameize {
+		g := Generator{
+			trimPrefix: ts.TrimPrefix,
+			trimSuffix: ts.TrimSuffix,
+		}
+
+		got := g.nameize(ts.Name)
+		if got != ts.Want {
+			t.Errorf("Got: %s\nWant: %s", got, ts.Want)
+		}
+	}
+}
+
+type QuoteSet struct {
+	Dialect string
+	Name    string
+	Want    string
+}
+
+var (
+	TestSetQuote = []QuoteSet{
+		{
+			Dialect: dialectMySQL,
+			Name:    "order",
+			Want:    "`order`",
+		},
+		{
+			Dialect: dialectMySQL,
+			Name:    "na`me",
+			Want:    "`na``me`",
+		},
+		{
+			Dialect: dialectPostgres,
+			Name:    "group",
+			Want:    `\"group\"`,
+		},
+		{
+			Dialect: dialectPostgres,
+			Name:    `na"me`,
+			Want:    `\"na\"\"me\"`,
+		},
+		{
+			Dialect: dialectSQLite,
+			Name:    "order",
+			Want:    `\"order\"`,
+		},
+	}
+)
+
+func TestQuote(t *testing.T) {
+	for _, ts := range TestSetQuote {
+		g := Generator{
+			dialect: ts.Dialect,
+		}
+
+		got := g.quote(ts.Name)
+		if got != ts.Want {
+			t.Errorf("Got: %s\nWant: %s", got, ts.Want)
+		}
+	}
+}
+
+func TestValidColumn(t *testing.T) {
+	for _, name := range []string{"select", "created_at", "base.id"} {
+		if !validColumn(name) {
+			t.Errorf("Got: invalid\nWant: valid column %q", name)
+		}
+	}
+
+	for _, name := range []string{"", "na`me", "a b", `na"me`, "id;"} {
+		if validColumn(name) {
+			t.Errorf("Got: valid\nWant: invalid column %q", name)
+		}
+	}
+}
+
+func TestTableDirective(t *testing.T) {
+	src := `package models
+
+// Alert is an alert.
+//beagle:table=alerts
+type Alert struct{}
+
+type (
+	//beagle:table=users
+	User struct{}
+
+	Role struct{}
+)
+`
+
+	f, err := parser.ParseFile(token.NewFileSet(), "models.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"Alert": "alerts",
+		"User":  "users",
+		"Role":  "",
+	}
+
+	for _, decl := range f.Decls {
+		gd := decl.(*ast.GenDecl)
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+
+			got := tableDirective(ts.Doc, gd.Doc)
+			if got != want[ts.Name.Name] {
+				t.Errorf("Got: %s\nWant: %s", got, want[ts.Name.Name])
+			}
+		}
+	}
+}
+
+// generateSource returns the code generated for the types declared in src,
+// src can't import other packages.
+func generateSource(t *testing.T, g *Generator, src string, typeNames ...string) string {
+	fset := token.NewFileSet()
+
+	f, err := parser.ParseFile(fset, "models.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := &types.Info{
+		Defs: map[*ast.Ident]types.Object{},
+	}
+
+	conf := &types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+	}
+
+	if _, err := conf.Check("models", fset, []*ast.File{f}, info); err != nil {
+		t.Fatal(err)
+	}
+
+	g.setPackage("models", info.Defs, []*ast.File{f})
+
+	for _, typeName := range typeNames {
+		g.generate(typeName)
+	}
+
+	return string(g.format())
+}
+
+func TestGenerateTypesInFile(t *testing.T) {
+	src := `package models
+
+//beagle:table=alerts
+type Alert struct {
+	ID     int ` + "`db:\"id,primary\"`" + `
+	Status int ` + "`db:\"status\"`" + `
+}
+
+//beagle:table=users
+type User struct {
+	ID   int    ` + "`db:\"id,primary\"`" + `
+	Name string ` + "`db:\"name\"`" + `
+}
+`
+
+	g := Generator{
+		tagName: "db",
+	}
+
+	got := generateSource(t, &g, src, "Alert", "User")
+
+	for _, fn := range []string{"func (s *Alert) Get(", "func (s *User) Get(", "func QueryAlerts()", "func QueryUsers()"} {
+		if n := strings.Count(got, fn); n != 1 {
+			t.Errorf("Got: %d times %s\nWant: once", n, fn)
+		}
+	}
+
+	for typeName, fields := range map[string][]string{
+		"Alert": {"AlertID", "AlertStatus"},
+		"User":  {"UserID", "UserName"},
+	} {
+		query := got[strings.Index(got, "func Query"+typeName+"s()"):]
+		query = strings.Join(strings.Fields(query[:strings.Index(query, "}")]), " ")
+
+		want := "Fields( " + strings.Join(fields, ", ") + ", )"
+		if !strings.Contains(query, want) {
+			t.Errorf("Got: %s\nWant: %s", query, want)
+		}
+	}
+}
+
+func TestGenerateOmitEmpty(t *testing.T) {
+	src := `package models
+
+//beagle:table=alerts
+type Alert struct {
+	ID   int     ` + "`db:\"id,primary\"`" + `
+	Name *string ` + "`db:\"name,omitempty\"`" + `
+}
+`
+
+	g := Generator{
+		tagName: "db",
+	}
+
+	got := generateSource(t, &g, src, "Alert")
+
+	want := "ON DUPLICATE KEY UPDATE `id`=:id, `name`=COALESCE(:name, `alerts`.`name`)\""
+	if !strings.Contains(got, want) {
+		t.Errorf("Got: %s\nWant: %s", got, want)
+	}
+}
+
+func TestGenerateJSON(t *testing.T) {
+	src := `package models
+
+//beagle:table=alerts
+type Alert struct {
+	ID      int                    ` + "`db:\"id,primary\"`" + `
+	Payload map[string]interface{} ` + "`db:\"payload,json\"`" + `
+}
+`
+
+	g := Generator{
+		tagName: "db",
+	}
+
+	got := generateSource(t, &g, src, "Alert")
+
+	for _, want := range []string{
+		`"payload": db.JSON{V: &s.Payload},`,
+		`&db.JSON{V: &s.Payload},`,
+		`tx.NamedExec(string(queryAlertInsert), s.namedValues())`,
+		`stmt.QueryRowx(s.namedValues()).Scan(s.scanValues()...)`,
+		`func (s *Alert) ScanRow(rows *sqlx.Rows) error {`,
+		`"payload": &db.JSON{V: &s.Payload},`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Got: %s\nWant: %s", got, want)
+		}
+	}
+}
+
+func TestGenerateUnique(t *testing.T) {
+	src := `package models
+
+//beagle:table=users
+type User struct {
+	ID    int    ` + "`db:\"id,primary\"`" + `
+	Email string ` + "`db:\"email,unique\"`" + `
+}
+`
+
+	g := Generator{
+		tagName: "db",
+	}
+
+	got := generateSource(t, &g, src, "User")
+
+	for _, want := range []string{
+		"queryUserSelect + \" WHERE `email`=:email\"",
+		"tx.PrepareNamed(string(queryUserGetByEmail))",
+		"func (s *User) GetByEmail(tx *sqlx.Tx) error {",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Got: %s\nWant: %s", got, want)
+		}
+	}
+}
+
+// generatedQueries returns the string values of the query variables in the
+// generated source, by name.
+func generatedQueries(t *testing.T, src string) map[string]string {
+	f, err := parser.ParseFile(token.NewFileSet(), "models_gen.go", "package models\n"+src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queries := map[string]string{}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok || len(spec.Values) != 1 {
+			return true
+		}
+
+		lit, ok := spec.Values[0].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+
+		value, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		queries[spec.Names[0].Name] = value
+		return true
+	})
+
+	return queries
+}
+
+func TestGenerateQueries(t *testing.T) {
+	src := `package models
+
+//beagle:table=alerts
+type Alert struct {
+	ID      int    ` + "`db:\"id,primary\"`" + `
+	Name    string ` + "`db:\"name\"`" + `
+	Deleted bool   ` + "`db:\"deleted\"`" + `
+}
+`
+
+	g := Generator{
+		tagName:          "db",
+		softDeleteColumn: "deleted",
+		softDeleteValue:  "1",
+	}
+
+	got := generateSource(t, &g, src, "Alert")
+
+	// the flag is 1 on the deleted rows, so the live rows are 0
+	if want := "SoftDelete(db.False(AlertDeleted))"; strings.Count(got, want) != 2 {
+		t.Errorf("Got: %s\nWant: %s in QueryAlerts and CountAlerts", got, want)
+	}
+
+	queries := generatedQueries(t, got)
+
+	for name, want := range map[string]string{
+		"queryAlertDelete":     "UPDATE `alerts` SET `deleted` = 1 WHERE `id`=:id",
+		"queryAlertDeleteHard": "DELETE FROM `alerts` WHERE `id`=:id",
+		"queryAlertSelect":     "SELECT `id`, `name`, `deleted` FROM `alerts`",
+		"queryAlertExists":     "SELECT 1 FROM `alerts` WHERE `id`=:id LIMIT 1",
+		"queryAlertCount":      "SELECT COUNT(*) FROM `alerts`",
+		"queryAlertUpdate":     "UPDATE `alerts` SET `id`=:id, `name`=:name, `deleted`=:deleted WHERE `id`=:id",
+		"queryAlertInsert":     "INSERT INTO `alerts` (`id`, `name`, `deleted`) VALUES (:id, :name, :deleted)",
+	} {
+		if got := queries[name]; got != want {
+			t.Errorf("%s\nGot: %q\nWant: %q", name, got, want)
+		}
+	}
+}
+
+func TestGenerateDefaultKey(t *testing.T) {
+	src := `package models
+
+//beagle:table=alerts
+type Alert struct {
+	ID   int    ` + "`db:\"id\"`" + `
+	Name string ` + "`db:\"name\"`" + `
+}
+`
+
+	g := Generator{
+		tagName: "db",
+	}
+
+	queries := generatedQueries(t, generateSource(t, &g, src, "Alert"))
+
+	if want := "UPDATE `alerts` SET `id`=:id, `name`=:name WHERE `id`=:id"; queries["queryAlertUpdate"] != want {
+		t.Errorf("Got: %q\nWant: %q", queries["queryAlertUpdate"], want)
+	}
+
+	// the tagged key takes precedence over the id column
+	tagged := strings.Replace(src, `db:"name"`, `db:"name,primary"`, 1)
+
+	g = Generator{
+		tagName: "db",
+	}
+
+	queries = generatedQueries(t, generateSource(t, &g, tagged, "Alert"))
+
+	if want := "UPDATE `alerts` SET `id`=:id, `name`=:name WHERE `name`=:name"; queries["queryAlertUpdate"] != want {
+		t.Errorf("Got: %q\nWant: %q", queries["queryAlertUpdate"], want)
+	}
+}
+
+func TestGenerateKeyAuto(t *testing.T) {
+	src := `package models
+
+//beagle:table=alerts
+type Alert struct {
+	ID   int64  ` + "`db:\"id,primary\"`" + `
+	Name string ` + "`db:\"name\"`" + `
+}
+`
+
+	for _, ts := range []struct {
+		Dialect string
+		Query   string
+		Want    string
+	}{
+		{dialectMySQL, "INSERT INTO `alerts` (`name`) VALUES (:name)", "s.ID = int64(id)"},
+		{dialectPostgres, `INSERT INTO "alerts" ("name") VALUES (:name) RETURNING "id"`, "return stmt.Get(&s.ID, s)"},
+	} {
+		g := Generator{
+			tagName: "db",
+			dialect: ts.Dialect,
+			keyAuto: true,
+		}
+
+		got := generateSource(t, &g, src, "Alert")
+
+		queries := generatedQueries(t, got)
+		if query := queries["queryAlertInsert"]; query != ts.Query {
+			t.Errorf("%s\nGot: %q\nWant: %q", ts.Dialect, query, ts.Query)
+		}
+
+		// inserting many rows leaves out the key, an insert or update
+		// needs it to find the row.
+		if query := queries["queryAlertInsertMany"]; strings.Contains(query, "id") {
+			t.Errorf("%s\nGot: %q\nWant: no id column", ts.Dialect, query)
+		}
+
+		if query := queries["queryAlertInsertOrUpdate"]; !strings.Contains(query, ":id") {
+			t.Errorf("%s\nGot: %q\nWant: the id column", ts.Dialect, query)
+		}
+
+		if !strings.Contains(got, ts.Want) {
+			t.Errorf("%s\nGot: %s\nWant: %s", ts.Dialect, got, ts.Want)
+		}
+	}
+}
+
+func TestGenerateSoftDelete(t *testing.T) {
+	src := `package models
+
+//beagle:table=alerts
+type Alert struct {
+	ID     int  ` + "`db:\"id,primary\"`" + `
+	Active bool ` + "`db:\"active\"`" + `
+}
+`
+
+	for _, ts := range []struct {
+		Dialect string
+		Value   string
+		Want    string
+		Delete  string
+	}{
+		{dialectMySQL, "0", "SoftDelete(db.True(AlertActive))", "UPDATE `alerts` SET `active` = 0 WHERE `id`=:id"},
+		{dialectMySQL, "FALSE", "SoftDelete(db.True(AlertActive))", "UPDATE `alerts` SET `active` = FALSE WHERE `id`=:id"},
+		{dialectMySQL, "1", "SoftDelete(db.False(AlertActive))", "UPDATE `alerts` SET `active` = 1 WHERE `id`=:id"},
+		{dialectMySQL, "TRUE", "SoftDelete(db.False(AlertActive))", "UPDATE `alerts` SET `active` = TRUE WHERE `id`=:id"},
+		{dialectMySQL, "NOW()", "SoftDelete(db.IsNull(AlertActive))", "UPDATE `alerts` SET `active` = NOW() WHERE `id`=:id"},
+		{dialectPostgres, softDeleteDefault(dialectPostgres), "SoftDelete(db.True(AlertActive))", `UPDATE "alerts" SET "active" = FALSE WHERE "id"=:id`},
+	} {
+		g := Generator{
+			tagName:          "db",
+			dialect:          ts.Dialect,
+			softDeleteColumn: "active",
+			softDeleteValue:  ts.Value,
+		}
+
+		got := generateSource(t, &g, src, "Alert")
+
+		if queries := generatedQueries(t, got); queries["queryAlertDelete"] != ts.Delete {
+			t.Errorf("Got: %q\nWant: %q", queries["queryAlertDelete"], ts.Delete)
+		}
+
+		if strings.Count(got, ts.Want) != 2 {
+			t.Errorf("Got: %s\nWant: %s in QueryAlerts and CountAlerts", got, ts.Want)
+		}
+
+		for _, want := range []string{
+			"// Unlike QueryAlerts, it includes the soft deleted rows.\nfunc (s *Alert) GetByKey(",
+			"// Unlike QueryAlerts, it includes the soft deleted rows.\nfunc (s *Alert) Exists(",
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("Got: %s\nWant: %s", got, want)
+			}
+		}
+	}
+
+	g := Generator{
+		tagName: "db",
+	}
+
+	if got := generateSource(t, &g, src, "Alert"); strings.Contains(got, "soft deleted rows") {
+		t.Errorf("Got: %s\nWant: no soft delete note without -softdelete-column", got)
+	}
+}
+
+func TestGenerateInsertOrUpdateReturning(t *testing.T) {
+	src := `package models
+
+//beagle:table=alerts
+type Alert struct {
+	ID   int    ` + "`db:\"id,primary\"`" + `
+	Name string ` + "`db:\"name\"`" + `
+}
+`
+
+	g := Generator{
+		tagName: "db",
+		dialect: dialectPostgres,
+	}
+
+	got := generateSource(t, &g, src, "Alert")
+
+	want := `queryAlertInsertOrUpdate + " RETURNING \"id\", \"name\""`
+	if !strings.Contains(got, want) {
+		t.Errorf("Got: %s\nWant: %s", got, want)
+	}
+
+	g = Generator{
+		tagName: "db",
+		dialect: dialectMySQL,
+	}
+
+	got = generateSource(t, &g, src, "Alert")
+	if !strings.Contains(got, "return s.GetByKey(tx)") {
+		t.Errorf("Got: %s\nWant: InsertOrUpdateReturning selecting the row by key", got)
+	}
+}
+
+func TestPrepareOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "beagle-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	prepareOutput(filepath.Join(dir, "gen", "models", "alert_gen.go"))
+
+	if !isDirectory(filepath.Join(dir, "gen", "models")) {
+		t.Errorf("Got: no directory\nWant: %s", filepath.Join(dir, "gen", "models"))
+	}
+}
+
+func TestGenerateSQLite(t *testing.T) {
+	src := `package models
+
+//beagle:table=alerts
+type Alert struct {
+	ID   int    ` + "`db:\"id,primary\"`" + `
+	Name string ` + "`db:\"name\"`" + `
+}
+`
+
+	g := Generator{
+		tagName: "db",
+		dialect: dialectSQLite,
+	}
+
+	queries := generatedQueries(t, generateSource(t, &g, src, "Alert"))
+
+	want := `INSERT INTO "alerts" ("id", "name") VALUES (:id, :name) ON CONFLICT ("id") DO UPDATE SET "id"=:id, "name"=:name`
+	if got := queries["queryAlertInsertOrUpdate"]; got != want {
+		t.Errorf("Got: %q\nWant: %q", got, want)
+	}
+}
+
+func TestGenerateSchema(t *testing.T) {
+	src := `package models
+
+//beagle:table=alerts
+type Alert struct {
+	ID   int    ` + "`db:\"id,primary\"`" + `
+	Name string ` + "`db:\"name\"`" + `
+}
+`
+
+	g := Generator{
+		tagName: "db",
+		schema:  "analytics",
+	}
+
+	got := generateSource(t, &g, src, "Alert")
+	queries := generatedQueries(t, got)
+
+	for name, want := range map[string]string{
+		"AlertAlerts":      "`analytics`.`alerts`",
+		"AlertName":        "`analytics`.`alerts`.`name`",
+		"queryAlertSelect": "SELECT `id`, `name` FROM `analytics`.`alerts`",
+		"queryAlertInsert": "INSERT INTO `analytics`.`alerts` (`id`, `name`) VALUES (:id, :name)",
+		"queryAlertUpdate": "UPDATE `analytics`.`alerts` SET `id`=:id, `name`=:name WHERE `id`=:id",
+	} {
+		if got := queries[name]; got != want {
+			t.Errorf("%s\nGot: %q\nWant: %q", name, got, want)
+		}
+	}
+}
+
+func TestGenerateColumnValues(t *testing.T) {
+	src := `package models
+
+//beagle:table=alerts
+type Alert struct {
+	ID      int                    ` + "`db:\"id,primary\"`" + `
+	Payload map[string]interface{} ` + "`db:\"payload,json\"`" + `
+}
+`
+
+	g := Generator{
+		tagName: "db",
+	}
+
+	got := generateSource(t, &g, src, "Alert")
+
+	for _, want := range []string{
+		"func (s *Alert) ColumnValues() map[string]interface{} {",
+		`"id":      s.ID,`,
+		`"payload": s.Payload,`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Got: %s\nWant: %s", got, want)
+		}
+	}
+}
+
+func TestGenerateColumnValuesEmbeddedPointer(t *testing.T) {
+	src := `package models
+
+type Base struct {
+	Note string ` + "`db:\"note\"`" + `
+}
+
+//beagle:table=alerts
+type Alert struct {
+	*Base
+	ID      int                    ` + "`db:\"id,primary\"`" + `
+	Payload map[string]interface{} ` + "`db:\"payload,json\"`" + `
+}
+`
+
+	g := Generator{
+		tagName: "db",
+	}
+
+	got := generateSource(t, &g, src, "Alert")
+
+	// ColumnValues and namedValues
+	if want := "if s.Base != nil {\n\t\tvalues[\"note\"] = s.Base.Note\n\t}"; strings.Count(got, want) != 2 {
+		t.Errorf("Got: %s\nWant: %s in ColumnValues and namedValues", got, want)
+	}
+
+	for _, want := range []string{
+		`"note":    nil,`,
+		"if s.Base == nil {\n\t\ts.Base = new(Base)\n\t}\n\n\treturn []interface{}{",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Got: %s\nWant: %s", got, want)
+		}
+	}
+}
+
+func TestGenerateClone(t *testing.T) {
+	src := `package models
+
+type Base struct {
+	Tags []byte ` + "`db:\"tags\"`" + `
+}
+
+//beagle:table=alerts
+type Alert struct {
+	*Base
+	ID    int     ` + "`db:\"id,primary\"`" + `
+	Owner *string ` + "`db:\"owner\"`" + `
+}
+`
+
+	g := Generator{
+		tagName: "db",
+	}
+
+	got := generateSource(t, &g, src, "Alert")
+
+	for _, want := range []string{
+		"func (s *Alert) Clone() *Alert {",
+		"v := *s.Base\n\t\tc.Base = &v",
+		"if s.Base != nil {\n\t\tc.Base.Tags = append(s.Base.Tags[:0:0], s.Base.Tags...)\n\t}",
+		"c.ID = s.ID",
+		"v := *s.Owner\n\t\tc.Owner = &v",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Got: %s\nWant: %s", got, want)
+		}
+	}
+}
+
+func TestGenerateValidate(t *testing.T) {
+	src := `package models
+
+//beagle:table=alerts
+type Alert struct {
+	ID   int    ` + "`db:\"id,primary\"`" + `
+	Name string ` + "`db:\"name\"`" + `
+}
+`
+
+	for _, ts := range []struct {
+		Dialect string
+		Want    int
+	}{
+		{dialectMySQL, 5},
+		{dialectPostgres, 6},
+	} {
+		g := Generator{
+			tagName: "db",
+			dialect: ts.Dialect,
+		}
+
+		got := generateSource(t, &g, src, "Alert")
+
+		// Insert, Insert<Type>s, InsertIgnore, Update and InsertOrUpdate, postgres
+		// doesn't call InsertOrUpdate in InsertOrUpdateReturning.
+		if n := strings.Count(got, "interface{}(s).(db.Validator)"); n != ts.Want {
+			t.Errorf("%s\nGot: %d validations\nWant: %d", ts.Dialect, n, ts.Want)
+		}
+	}
+}
+
+func TestTagList(t *testing.T) {
+	var tags tagList
+
+	for _, value := range []string{"integration,mysql", " linux  go1.12 ", ",,"} {
+		if err := tags.Set(value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got, want := tags.String(), "integration,mysql,linux,go1.12"; got != want {
+		t.Errorf("Got: %s\nWant: %s", got, want)
+	}
+
+	if err := tags.Set("integration,!mysql"); err == nil {
+		t.Errorf("Got: no error\nWant: error for an invalid tag")
+	}
+}
+
+func TestGenerateConstsOnly(t *testing.T) {
+	src := `package models
+
+//beagle:table=alerts
+type Alert struct {
+	Name string ` + "`db:\"name\"`" + `
+}
+`
+
+	g := Generator{
+		tagName:    "db",
+		constsOnly: true,
+	}
+
+	got := generateSource(t, &g, src, "Alert")
+
+	if !strings.Contains(got, "func QueryAlerts() db.Queryx {") {
+		t.Errorf("Got: %s\nWant: QueryAlerts", got)
+	}
+
+	if strings.Contains(got, "func (s *Alert)") {
+		t.Errorf("Got: %s\nWant: no methods", got)
+	}
+}
+
+func TestGenerateInsertOrUpdateCreated(t *testing.T) {
+	id := "ID int `db:\"id,primary\"`\n"
+	name := "Name string `db:\"name\"`\n"
+	created := "CreatedAt time.Time `db:\"created_at\"`\n"
+
+	for _, fields := range [][]string{
+		{created, id, name},
+		{id, created, name},
+		{id, name, created},
+	} {
+		src := "package models\n\nimport \"time\"\n\n//beagle:table=alerts\ntype Alert struct {\n" + strings.Join(fields, "") + "}\n"
+
+		g := Generator{
+			tagName:       "db",
+			createdColumn: "created_at",
+		}
+
+		queries := generatedQueries(t, generateSource(t, &g, src, "Alert"))
+
+		want := " ON DUPLICATE KEY UPDATE `id`=:id, `name`=:name"
+		if got := queries["queryAlertInsertOrUpdate"]; !strings.HasSuffix(got, want) {
+			t.Errorf("Got: %q\nWant: ...%q", got, want)
+		}
+	}
+}
+
+func TestGenerateTimestampsUTC(t *testing.T) {
+	src := `package models
+
+import "time"
+
+//beagle:table=alerts
+type Alert struct {
+	ID        int       ` + "`db:\"id,primary\"`" + `
+	CreatedAt time.Time ` + "`db:\"created_at\"`" + `
+	UpdatedAt time.Time ` + "`db:\"updated_at\"`" + `
+}
+`
+
+	for _, ts := range []struct {
+		LocalTime bool
+		Want      string
+	}{
+		{false, "s.UpdatedAt = time.Now().UTC()\n"},
+		{true, "s.UpdatedAt = time.Now()\n"},
+	} {
+		g := Generator{
+			tagName:       "db",
+			createdColumn: "created_at",
+			updatedColumn: "updated_at",
+			localTime:     ts.LocalTime,
+		}
+
+		got := generateSource(t, &g, src, "Alert")
+
+		if !strings.Contains(got, ts.Want) {
+			t.Errorf("Got: %s\nWant: %q", got, ts.Want)
+		}
+
+		if utc := strings.Contains(got, ".UTC()"); utc == ts.LocalTime {
+			t.Errorf("local time %t\nGot: UTC %t", ts.LocalTime, utc)
+		}
+	}
+}
+
+func TestDefaultOutputName(t *testing.T) {
+	for typeName, want := range map[string]string{
+		"Alert":      "Alert_gen.go",
+		"UserRole":   "UserRole_gen.go",
+		"HTTPStatus": "HTTPStatus_gen.go",
+	} {
+		if got := defaultOutputName(typeName); got != want {
+			t.Errorf("Got: %s\nWant: %s", got, want)
+		}
+	}
+}
+
+func TestOutputFileName(t *testing.T) {
+	for _, ts := range []struct {
+		Template string
+		Want     string
+	}{
+		{"{{.Type}}_crud.go", "UserRole_crud.go"},
+		{"{{lower .Type}}_gen.go", "userrole_gen.go"},
+		{"{{snake .Type}}_gen.go", "user_role_gen.go"},
+		{`{{join .Types "_"}}_gen.go`, "UserRole_Alert_gen.go"},
+	} {
+		got, err := outputFileName(ts.Template, []string{"UserRole", "Alert"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != ts.Want {
+			t.Errorf("Got: %s\nWant: %s", got, ts.Want)
+		}
+	}
+
+	for _, tmpl := range []string{"{{.Type", "{{.Type}}.txt", "{{.Missing}}.go"} {
+		if _, err := outputFileName(tmpl, []string{"Alert"}); err == nil {
+			t.Errorf("Got: no error\nWant: error for %s", tmpl)
+		}
+	}
+}
+
+func TestCommandArgs(t *testing.T) {
+	got := strings.Join(commandArgs([]string{"-type", "Alert", "-check", "--check=true", "-table", "check"}), " ")
+	if want := "-type Alert -table check"; got != want {
+		t.Errorf("Got: %s\nWant: %s", got, want)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for name, want := range map[string]string{
+		"ID":         "id",
+		"Name":       "name",
+		"CreatedAt":  "created_at",
+		"UserID":     "user_id",
+		"HTTPStatus": "http_status",
+		"Address2":   "address2",
+	} {
+		if got := snakeCase(name); got != want {
+			t.Errorf("%s\nGot: %s\nWant: %s", name, got, want)
+		}
+	}
+}
+
+func TestGenerateInferColumns(t *testing.T) {
+	src := `package models
+
+//beagle:table=alerts
+type Alert struct {
+	ID        int    ` + "`db:\"id,primary\"`" + `
+	CreatedBy string
+	Secret    string ` + "`db:\"-\"`" + `
+	state     int
+}
+`
+
+	g := Generator{
+		tagName:   "db",
+		inferCols: true,
+	}
+
+	got := generateSource(t, &g, src, "Alert")
+
+	want := "SELECT `id`, `created_by` FROM `alerts`"
+	if query := generatedQueries(t, got)["queryAlertSelect"]; query != want {
+		t.Errorf("Got: %q\nWant: %q", query, want)
+	}
+}
+
+func TestGenerateEnums(t *testing.T) {
+	src := `package models
+
+type Status int
+
+const (
+	StatusOpen Status = iota
+	StatusClosed
+	StatusDone = StatusClosed
+	StatusUnknown Status = -1
+)
+
+//beagle:table=alerts
+type Alert struct {
+	ID     int    ` + "`db:\"id,primary\"`" + `
+	Status Status ` + "`db:\"status\"`" + `
+	Prev   Status ` + "`db:\"prev\"`" + `
+}
+`
+
+	g := Generator{
+		tagName:    "db",
+		trimPrefix: "Status",
+	}
+
+	got := generateSource(t, &g, src, "Alert")
+
+	want := `var StatusNames = map[Status]string{
+	StatusUnknown: "Unknown",
+	StatusOpen:    "Open",
+	StatusClosed:  "Closed",
+}`
+	if !strings.Contains(got, want) {
+		t.Errorf("Got: %s\nWant: %s", got, want)
+	}
+
+	if n := strings.Count(got, "var StatusNames"); n != 1 {
+		t.Errorf("Got: %d maps\nWant: 1", n)
+	}
+}
+
+func TestGenerateConflictColumns(t *testing.T) {
+	src := `package models
+
+//beagle:table=alerts
+type Alert struct {
+	ID       int    ` + "`db:\"id,primary\"`" + `
+	TenantID int    ` + "`db:\"tenant_id\"`" + `
+	Name     string ` + "`db:\"name\"`" + `
+}
+`
+
+	for _, ts := range []struct {
+		Conflict []string
+		Want     string
+	}{
+		{nil, `ON CONFLICT ("id") DO UPDATE`},
+		{[]string{"tenant_id", "name"}, `ON CONFLICT ("tenant_id", "name") DO UPDATE`},
+	} {
+		g := Generator{
+			tagName:         "db",
+			dialect:         dialectPostgres,
+			conflictColumns: ts.Conflict,
+		}
+
+		got := generateSource(t, &g, src, "Alert")
+
+		if query := generatedQueries(t, got)["queryAlertInsertOrUpdate"]; !strings.Contains(query, ts.Want) {
+			t.Errorf("Got: %q\nWant: %s", query, ts.Want)
+		}
+	}
+}
+
+func TestGenerateUpdateNonZero(t *testing.T) {
+	src := `package models
+
+type Base struct {
+	Name string ` + "`db:\"name\"`" + `
+}
+
+//beagle:table=alerts
+type Alert struct {
+	*Base
+	ID     int ` + "`db:\"id,primary\"`" + `
+	Status int ` + "`db:\"status\"`" + `
+}
+`
+
+	g := Generator{
+		tagName: "db",
+	}
+
+	got := generateSource(t, &g, src, "Alert")
+
+	for _, want := range []string{
+		"func (s *Alert) UpdateNonZero(tx *sqlx.Tx) error {",
+		"if s.Base != nil && !db.IsZero(s.Base.Name) {",
+		"if !db.IsZero(s.Status) {",
+		"return s.UpdateFields(tx, columns...)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Got: %s\nWant: %s", got, want)
+		}
+	}
+
+	// the key selects the row
+	if strings.Contains(got, "db.IsZero(s.ID)") {
+		t.Errorf("Got: %s\nWant: no zero check of the key", got)
+	}
+}
+
+func TestGenerateInsertIgnore(t *testing.T) {
+	src := `package models
+
+//beagle:table=alerts
+type Alert struct {
+	ID   int    ` + "`db:\"id,primary\"`" + `
+	Name string ` + "`db:\"name\"`" + `
+}
+`
+
+	for _, ts := range []struct {
+		Dialect string
+		Want    string
+	}{
+		{dialectMySQL, "INSERT IGNORE INTO `alerts` (`id`, `name`) VALUES (:id, :name)"},
+		{dialectPostgres, `INSERT INTO "alerts" ("id", "name") VALUES (:id, :name) ON CONFLICT DO NOTHING`},
+	} {
+		g := Generator{
+			tagName: "db",
+			dialect: ts.Dialect,
+		}
+
+		got := generateSource(t, &g, src, "Alert")
+
+		if query := generatedQueries(t, got)["queryAlertInsertIgnore"]; query != ts.Want {
+			t.Errorf("Got: %q\nWant: %q", query, ts.Want)
+		}
+
+		if !strings.Contains(got, "func (s *Alert) InsertIgnore(tx *sqlx.Tx) error {") {
+			t.Errorf("InsertIgnore not generated for %s", ts.Dialect)
+		}
+	}
+}
+
+func TestGenerateDeleteMany(t *testing.T) {
+	src := `package models
+
+//beagle:table=alerts
+type Alert struct {
+	ID     int  ` + "`db:\"id,primary\"`" + `
+	Active bool ` + "`db:\"active\"`" + `
+}
+`
+
+	g := Generator{
+		tagName:          "db",
+		softDeleteColumn: "active",
+		softDeleteValue:  "0",
+	}
+
+	got := generateSource(t, &g, src, "Alert")
+
+	want := "UPDATE `alerts` SET `active` = 0 WHERE `id` IN (?)"
+	if query := generatedQueries(t, got)["queryAlertDeleteMany"]; query != want {
+		t.Errorf("Got: %q\nWant: %q", query, want)
+	}
+
+	if !strings.Contains(got, "func DeleteAlerts(tx *sqlx.Tx, keys []interface{}) error {") {
+		t.Errorf("Got: %s\nWant: DeleteAlerts", got)
+	}
+
+	// the keys of composite keys can't be listed
+	g = Generator{
+		tagName:          "db",
+		softDeleteColumn: "active",
+		softDeleteValue:  "0",
+		tableKeys:        []string{"id", "active"},
+	}
+
+	got = generateSource(t, &g, strings.Replace(src, ",primary", "", 1), "Alert")
+
+	if strings.Contains(got, "DeleteAlerts") {
+		t.Errorf("Got: %s\nWant: no DeleteAlerts for a composite key", got)
+	}
+}
+
+func TestGenerateGetByKeys(t *testing.T) {
+	src := `package models
+
+//beagle:table=alerts
+type Alert struct {
+	ID      int                    ` + "`db:\"id,primary\"`" + `
+	Payload map[string]interface{} ` + "`db:\"payload\"`" + `
+}
+`
+
+	g := Generator{
+		tagName: "db",
+	}
+
+	got := generateSource(t, &g, src, "Alert")
+
+	for _, want := range []string{
+		"func GetAlertsByKeys(tx *sqlx.Tx, keys []interface{}) ([]Alert, error) {",
+		"QueryAlerts().Where(db.In(AlertID, keys[:n])).Build()",
+		"rows.StructScan(&s)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Got: %s\nWant: %s", got, want)
+		}
+	}
+
+	// JSON columns are scanned through db.JSON
+	got = generateSource(t, &g, strings.Replace(src, `db:"payload"`, `db:"payload,json"`, 1), "Alert")
+
+	if !strings.Contains(got, "rows.Scan(s.scanValues()...)") {
+		t.Errorf("Got: %s\nWant: scanValues for JSON columns", got)
+	}
+
+	// the keys of composite keys can't be listed
+	g = Generator{
+		tagName:   "db",
+		tableKeys: []string{"id", "payload"},
+	}
+
+	got = generateSource(t, &g, strings.Replace(src, ",primary", "", 1), "Alert")
+
+	if strings.Contains(got, "GetAlertsByKeys") {
+		t.Errorf("Got: %s\nWant: no GetAlertsByKeys for a composite key", got)
+	}
+}
+
+func TestGenerateAlias(t *testing.T) {
+	src := `package models
+
+type internalAlert struct {
+	ID   int    ` + "`db:\"id,primary\"`" + `
+	Name string ` + "`db:\"name\"`" + `
+}
+
+//beagle:table=alerts
+type Alert = internalAlert
+
+//beagle:table=archived_alerts
+type ArchivedAlert internalAlert
+`
+
+	for _, typeName := range []string{"Alert", "ArchivedAlert"} {
+		g := Generator{
+			tagName: "db",
+		}
+
+		got := generateSource(t, &g, src, typeName)
+
+		for _, want := range []string{
+			typeName + "ID ",
+			typeName + "Name ",
+			"func (s *" + typeName + ") Update(tx *sqlx.Tx) error {",
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("Got: %s\nWant: %s", got, want)
+			}
+		}
+	}
+}
+
+func TestGenerateReadonly(t *testing.T) {
+	src := `package models
+
+//beagle:table=users
+type User struct {
+	ID       int    ` + "`db:\"id,primary\"`" + `
+	Name     string ` + "`db:\"name\"`" + `
+	FullName string ` + "`db:\"full_name,readonly\"`" + `
+}
+`
+
+	g := Generator{
+		tagName: "db",
+	}
+
+	got := generateSource(t, &g, src, "User")
+	queries := generatedQueries(t, got)
+
+	if query := queries["queryUserSelect"]; !strings.Contains(query, "`full_name`") {
+		t.Errorf("Got: %q\nWant: full_name selected", query)
+	}
+
+	for _, name := range []string{"queryUserUpdate", "queryUserInsert", "queryUserInsertIgnore", "queryUserInsertMany", "queryUserInsertOrUpdate"} {
+		if query := queries[name]; strings.Contains(query, "full_name") {
+			t.Errorf("%s\nGot: %q\nWant: no full_name", name, query)
+		}
+	}
+
+	if strings.Contains(got, "\"`full_name`\"") {
+		t.Errorf("Got: %s\nWant: full_name not updatable by UpdateFields", got)
+	}
+}
+
+func TestGenerateView(t *testing.T) {
+	src := `package models
+
+//beagle:view
+//beagle:table=alert_stats
+type AlertStat struct {
+	Status int ` + "`db:\"status\"`" + `
+	Count  int ` + "`db:\"count\"`" + `
+}
+`
+
+	g := Generator{
+		tagName: "db",
+	}
+
+	got := generateSource(t, &g, src, "AlertStat")
+
+	for _, want := range []string{"func QueryAlertStats() db.Queryx {", "func CountAlertStats() db.Queryx {"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Got: %s\nWant: %s", got, want)
+		}
+	}
+
+	for _, method := range []string{"Insert", "Update", "Delete", "GetByKey"} {
+		if strings.Contains(got, ") "+method+"(") {
+			t.Errorf("Got: %s\nWant: no %s for a view", got, method)
+		}
+	}
+
+	if g.writes {
+		t.Errorf("Got: writes\nWant: no write methods for a view")
+	}
+}
+
+func TestGenerateString(t *testing.T) {
+	src := `package models
+
+type Base struct {
+	Note string ` + "`db:\"note\"`" + `
+}
+
+//beagle:table=alerts
+type Alert struct {
+	*Base
+	ID   int    ` + "`db:\"id,primary\"`" + `
+	Name string ` + "`db:\"name\"`" + `
+}
+`
+
+	g := Generator{
+		tagName: "db",
+	}
+
+	if got := generateSource(t, &g, src, "Alert"); strings.Contains(got, "String() string") {
+		t.Errorf("Got: %s\nWant: no String without -stringer", got)
+	}
+
+	g = Generator{
+		tagName:  "db",
+		stringer: true,
+	}
+
+	got := generateSource(t, &g, src, "Alert")
+
+	for _, want := range []string{
+		"func (s *Alert) String() string {",
+		"if s.Base != nil {\n\t\tfmt.Fprintf(&b, \"note=%q\", s.Base.Note)",
+		"fmt.Fprintf(&b, \" id=%v\", s.ID)",
+		"fmt.Fprintf(&b, \" name=%q\", s.Name)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Got: %s\nWant: %s", got, want)
+		}
+	}
+
+	if !g.usesFmt {
+		t.Errorf("Got: no fmt import\nWant: fmt imported for String")
+	}
+}
+
+func TestRenameImport(t *testing.T) {
+	src := `package models
+
+import db "go.dutchsec.com/beagle/db"
+
+var queryAlertSelect db.Query = "SELECT id FROM alerts"
+
+func local(db *Conn) error {
+	return db.Close()
+}
+`
+
+	got, err := renameImport([]byte(src), "db", "beagledb")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`import beagledb "go.dutchsec.com/beagle/db"`,
+		"var queryAlertSelect beagledb.Query",
+		"return db.Close()",
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("Got: %s\nWant: %s", got, want)
+		}
+	}
+}
+
+func TestGenerateGroupable(t *testing.T) {
+	src := `package models
+
+//beagle:table=alerts
+type Alert struct {
+	ID     int    ` + "`db:\"id,primary\"`" + `
+	Status int    ` + "`db:\"status,groupable\"`" + `
+	Name   string ` + "`db:\"name\"`" + `
+}
+`
+
+	g := Generator{
+		tagName: "db",
+	}
+
+	got := generateSource(t, &g, src, "Alert")
+
+	for _, want := range []string{
+		"type AlertStatusCount struct {",
+		"Status int `db:\"status\"`",
+		"Count  int `db:\"count\"`",
+		"func QueryAlertCountByStatus() db.Queryx {",
+		"Fields(AlertStatus, db.As(db.Count(\"*\"), \"count\")).\n\t\tGroupBy(AlertStatus)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Got: %s\nWant: %s", got, want)
+		}
+	}
+
+	if strings.Contains(got, "AlertNameCount") {
+		t.Errorf("Got: %s\nWant: no count by the name column", got)
+	}
+}
+
+func TestGenerateUpdateFieldsVersion(t *testing.T) {
+	src := `package models
+
+//beagle:table=alerts
+type Alert struct {
+	ID      int    ` + "`db:\"id,primary\"`" + `
+	Name    string ` + "`db:\"name\"`" + `
+	Version int    ` + "`db:\"version\"`" + `
+}
+`
+
+	g := Generator{
+		tagName:       "db",
+		versionColumn: "version",
+	}
+
+	got := generateSource(t, &g, src, "Alert")
+
+	for _, want := range []string{
+		"if column != \"version\" {\n\t\t\tset = append(set, quoted+\"=:\"+column)",
+		"set = append(set, \"`version`=`version`+1\")",
+		"\" WHERE `id`=:id AND `version`=:version\"",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Got: %s\nWant: %s", got, want)
+		}
+	}
+
+	if want := "return db.ErrStaleObject\n\t}\n\n\ts.Version++\n\treturn nil\n}"; strings.Count(got, want) != 2 {
+		t.Errorf("Got: %s\nWant: %s in Update and UpdateFields", got, want)
+	}
+}
+
+func TestGenerateUpdateNonZeroVersion(t *testing.T) {
+	src := `package models
+
+//beagle:table=alerts
+type Alert struct {
+	ID      int    ` + "`db:\"id,primary\"`" + `
+	Name    string ` + "`db:\"name\"`" + `
+	Version int    ` + "`db:\"version\"`" + `
+}
+`
+
+	g := Generator{
+		tagName:       "db",
+		versionColumn: "version",
+	}
+
+	got := generateSource(t, &g, src, "Alert")
+
+	// UpdateFields matches and increments the version, even when no other
+	// column changed
+	if want := "func (s *Alert) UpdateNonZero(tx *sqlx.Tx) error {\n\tcolumns := []string{\"version\"}"; !strings.Contains(got, want) {
+		t.Errorf("Got: %s\nWant: %s", got, want)
+	}
+
+	if want := "!db.IsZero(s.Version)"; strings.Contains(got, want) {
+		t.Errorf("Got: %s\nWant: no %s", got, want)
+	}
+}
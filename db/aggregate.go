@@ -0,0 +1,57 @@
+// Copyright 2019 The DutchSec Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package db
+
+import "fmt"
+
+// Count returns the field counting the rows of the field, use "*" to count
+// all rows, eg. Fields(AlertStatus, db.Count("*")).GroupBy(AlertStatus).
+// It panics on invalid field names.
+func Count(field Field) Field {
+	return aggregate("COUNT", field)
+}
+
+// Max returns the field selecting the largest value of the field.
+func Max(field Field) Field {
+	return aggregate("MAX", field)
+}
+
+// Min returns the field selecting the smallest value of the field.
+func Min(field Field) Field {
+	return aggregate("MIN", field)
+}
+
+// Sum returns the field selecting the sum of the values of the field.
+func Sum(field Field) Field {
+	return aggregate("SUM", field)
+}
+
+// Avg returns the field selecting the average of the values of the field.
+func Avg(field Field) Field {
+	return aggregate("AVG", field)
+}
+
+// aggregate returns the field applying the aggregate function fn.
+func aggregate(fn string, field Field) Field {
+	if field == "*" {
+		return Field(fmt.Sprintf("%s(*)", fn))
+	}
+
+	name, err := sanitize(string(field))
+	if err != nil {
+		panic(fmt.Sprintf("db: invalid %s field: %s", fn, err))
+	}
+
+	return Field(fmt.Sprintf("%s(%s)", fn, name))
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"testing"
+)
+
+var (
+	TestSetGroupBy = []Set{
+		{
+			Query: SelectQuery("alerts").Fields("status", Count("*")).GroupBy("status"),
+			Want:  Query("SELECT status,COUNT(*) FROM alerts GROUP BY status "),
+		},
+		{
+			Query: SelectQuery("alerts").Fields("status", Max("alerts.created_at")).GroupBy("status").Where(Compare("active", "=", 1)),
+			Want:  Query("SELECT status,MAX(alerts.created_at) FROM alerts WHERE active = ? GROUP BY status "),
+		},
+	}
+)
+
+func TestGroupBy(t *testing.T) {
+	for _, ts := range TestSetGroupBy {
+		got, _ := ts.Query.Build()
+
+		if got != ts.Want {
+			t.Errorf("Got: %q\nWant: %q", got, ts.Want)
+		}
+	}
+}
+
+func TestAggregateInvalidField(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Got: no panic\nWant: panic for an invalid field")
+		}
+	}()
+
+	Sum("amount) FROM users; --")
+}
+
+func TestCountQuery(t *testing.T) {
+	q := SelectQuery("alerts").Fields("status", Count("*")).GroupBy("status")
+
+	got, _ := countQuery(q)
+
+	want := Query("SELECT COUNT(*) FROM (SELECT status,COUNT(*) FROM alerts GROUP BY status ) q")
+	if got != want {
+		t.Errorf("Got: %q\nWant: %q", got, want)
+	}
+
+	if got, _ := countQuery(SelectQuery("alerts").Fields(Count("*"))); got != "SELECT COUNT(*) FROM alerts " {
+		t.Errorf("Got: %q\nWant: %q", got, "SELECT COUNT(*) FROM alerts ")
+	}
+}
+
+func TestAs(t *testing.T) {
+	got, _ := SelectQuery("users").Fields(As("LOWER(email)", "email"), Count("*").Alias("total")).Build()
+
+	want := Query("SELECT LOWER(email) AS email,COUNT(*) AS total FROM users ")
+	if got != want {
+		t.Errorf("Got: %q\nWant: %q", got, want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Got: no panic\nWant: panic for an invalid alias")
+		}
+	}()
+
+	As("email", "x FROM users; --")
+}
@@ -0,0 +1,157 @@
+package db
+
+import "testing"
+
+func TestStatementCacheEviction(t *testing.T) {
+	tx, mock := mockTx(t)
+	tx.MaxStatements = 2
+
+	// the least recently used statement is closed for the third one
+	mock.ExpectPrepare("SELECT 1").WillBeClosed()
+	mock.ExpectPrepare("SELECT 2").WillBeClosed()
+	mock.ExpectPrepare("SELECT 3").WillBeClosed()
+
+	for _, q := range []Query{"SELECT 1", "SELECT 2", "SELECT 3"} {
+		if _, err := tx.Preparex(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if stats := tx.CacheStats(); stats.Evictions != 1 || stats.Size != 2 {
+		t.Errorf("Got: %+v\nWant: 1 eviction, size 2", stats)
+	}
+
+	// the evicted statement is prepared again
+	mock.ExpectPrepare("SELECT 1").WillBeClosed()
+
+	if _, err := tx.Preparex("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats := tx.CacheStats(); stats.Misses != 4 || stats.Hits != 0 {
+		t.Errorf("Got: %+v\nWant: 4 misses, 0 hits", stats)
+	}
+
+	mock.ExpectRollback()
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStatementCacheUnbounded(t *testing.T) {
+	tx, mock := mockTx(t)
+	tx.MaxStatements = 0
+
+	for _, q := range []Query{"SELECT 1", "SELECT 2", "SELECT 3"} {
+		mock.ExpectPrepare(string(q))
+
+		if _, err := tx.Preparex(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if stats := tx.CacheStats(); stats.Evictions != 0 || stats.Size != 3 {
+		t.Errorf("Got: %+v\nWant: no evictions, size 3", stats)
+	}
+
+	mock.ExpectRollback()
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStatementCacheHits(t *testing.T) {
+	tx, mock := mockTx(t)
+
+	// the repeated query is prepared once
+	mock.ExpectPrepare("SELECT 1")
+
+	for i := 0; i < 3; i++ {
+		if _, err := tx.Preparex("SELECT 1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if stats := tx.CacheStats(); stats.Hits != 2 || stats.Misses != 1 || stats.Size != 1 {
+		t.Errorf("Got: %+v\nWant: 2 hits, 1 miss, size 1", stats)
+	}
+
+	mock.ExpectRollback()
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStatementCacheClosed(t *testing.T) {
+	for _, commit := range []bool{true, false} {
+		tx, mock := mockTx(t)
+
+		mock.ExpectPrepare("SELECT 1").WillBeClosed()
+		mock.ExpectPrepare("SELECT 2").WillBeClosed()
+
+		for _, q := range []Query{"SELECT 1", "SELECT 2"} {
+			if _, err := tx.Preparex(q); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var err error
+		if commit {
+			mock.ExpectCommit()
+			err = tx.Commit()
+		} else {
+			mock.ExpectRollback()
+			err = tx.Rollback()
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stats := tx.CacheStats(); stats.Size != 0 {
+			t.Errorf("commit %t\nGot: %+v\nWant: size 0", commit, stats)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("commit %t\n%s", commit, err)
+		}
+	}
+}
+
+func TestClearStatementCache(t *testing.T) {
+	tx, mock := mockTx(t)
+
+	mock.ExpectPrepare("SELECT 1").WillBeClosed()
+
+	for i := 0; i < 2; i++ {
+		if _, err := tx.Preparex("SELECT 1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tx.ClearStatementCache()
+
+	if stats := tx.CacheStats(); stats != (StatementCacheStats{}) {
+		t.Errorf("Got: %+v\nWant: %+v", stats, StatementCacheStats{})
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// the statement is prepared again after clearing the cache
+	mock.ExpectPrepare("SELECT 1")
+
+	if _, err := tx.Preparex("SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectRollback()
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2019 The DutchSec Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package db
+
+import "fmt"
+
+// comparisons are the operators allowed by Compare.
+var comparisons = map[string]bool{
+	"=":        true,
+	"<>":       true,
+	"!=":       true,
+	"<":        true,
+	"<=":       true,
+	">":        true,
+	">=":       true,
+	"LIKE":     true,
+	"NOT LIKE": true,
+}
+
+// Compare returns the comparison of the field with the value, eg.
+// Compare(AlertStatus, "=", 42). The value is passed as parameter, the
+// operator has to be one of =, <>, !=, <, <=, >, >=, LIKE or NOT LIKE.
+func Compare(field Field, op string, value interface{}) Operator {
+	if !comparisons[op] {
+		panic(fmt.Sprintf("db: unsupported comparison operator %q", op))
+	}
+
+	return &compareOperator{field, op, value}
+}
+
+type compareOperator struct {
+	field Field
+	op    string
+	value interface{}
+}
+
+// Make TODO: NEEDS COMMENT INFO
+func (o *compareOperator) Make() (string, []interface{}) {
+	field, _ := o.field.Build()
+	return fmt.Sprintf("%s %s ?", field, o.op), []interface{}{o.value}
+}
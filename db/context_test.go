@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+// mockTx begins a transaction on sqlmock, the expectations are checked at
+// the end of the test.
+func mockTx(t *testing.T) (*Tx, sqlmock.Sqlmock) {
+	conn, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+
+		conn.Close()
+	})
+
+	mock.ExpectBegin()
+
+	tx, err := Begin(context.Background(), sqlx.NewDb(conn, "sqlmock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tx, mock
+}
+
+func TestGetxContext(t *testing.T) {
+	tx, mock := mockTx(t)
+
+	var alert struct {
+		ID int `db:"id"`
+	}
+
+	// objects without a Getter are scanned by sqlx, with and without a
+	// context
+	mock.ExpectPrepare("SELECT id FROM alerts WHERE id = ?").
+		ExpectQuery().WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	if err := tx.Getx(&alert, RawQuery("SELECT id FROM alerts WHERE id = ?", 1)); err != nil {
+		t.Fatal(err)
+	} else if alert.ID != 1 {
+		t.Errorf("Got: %d\nWant: 1", alert.ID)
+	}
+
+	mock.ExpectQuery("SELECT id FROM alerts WHERE id = ?").WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+
+	if err := tx.GetxContext(context.Background(), &alert, RawQuery("SELECT id FROM alerts WHERE id = ?", 2)); err != nil {
+		t.Fatal(err)
+	} else if alert.ID != 2 {
+		t.Errorf("Got: %d\nWant: 2", alert.ID)
+	}
+
+	mock.ExpectQuery("SELECT id FROM alerts WHERE id = ?").WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	if err := tx.Getx(&alert, RawQuery("SELECT id FROM alerts WHERE id = ?", 3)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Got: %v\nWant: %v", err, ErrNotFound)
+	}
+}
+
+func TestContextCanceled(t *testing.T) {
+	tx, _ := mockTx(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	qy := RawQuery("SELECT id FROM alerts")
+
+	var ids []int
+	if err := tx.SelectxContext(ctx, &ids, qy); !errors.Is(err, context.Canceled) {
+		t.Errorf("SelectxContext\nGot: %v\nWant: %v", err, context.Canceled)
+	}
+
+	var id struct {
+		ID int `db:"id"`
+	}
+
+	if err := tx.GetxContext(ctx, &id, qy); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetxContext\nGot: %v\nWant: %v", err, context.Canceled)
+	}
+
+	if _, err := tx.CountxContext(ctx, SelectQuery("alerts").Fields("COUNT(*)")); !errors.Is(err, context.Canceled) {
+		t.Errorf("CountxContext\nGot: %v\nWant: %v", err, context.Canceled)
+	}
+
+	if err := tx.ExecuteContext(ctx, RawQuery("DELETE FROM alerts")); !errors.Is(err, context.Canceled) {
+		t.Errorf("ExecuteContext\nGot: %v\nWant: %v", err, context.Canceled)
+	}
+}
@@ -16,7 +16,8 @@ package db
 import (
 	"context"
 	"database/sql"
-	"runtime"
+	"errors"
+	"runtime/debug"
 	"sync"
 	"time"
 
@@ -29,7 +30,7 @@ import (
 	logging "github.com/op/go-logging"
 )
 
-var log = logging.MustGetLogger("go.dutchsec.com/beagle/db")
+var log Logger = logging.MustGetLogger("go.dutchsec.com/beagle/db")
 
 // Newerer TODO: NEEDS COMMENT INFO
 type Newerer interface {
@@ -37,8 +38,10 @@ type Newerer interface {
 	setNew(bool)
 }
 
+// IsNoRowsErr reports whether the query returned no rows, wrapped errors
+// are unwrapped.
 func IsNoRowsErr(err error) bool {
-	return err == sql.ErrNoRows
+	return errors.Is(err, sql.ErrNoRows)
 }
 
 // New TODO: NEEDS COMMENT INFO
@@ -61,13 +64,21 @@ func Connect(driverName, dataSourceName string) (*DB, error) {
 	}
 
 	return &DB{
-		db,
+		DB: db,
 	}, nil
 }
 
 // DB TODO: NEEDS COMMENT INFO
 type DB struct {
 	*sqlx.DB
+
+	// Logger is the logger of the transactions of the database, when nil
+	// the default logger of the package is used.
+	Logger Logger
+
+	// OnQuery is the hook of the transactions of the database, see
+	// Tx.OnQuery.
+	OnQuery func(ctx context.Context, query string, dur time.Duration)
 }
 
 type selectOption interface {
@@ -76,6 +87,27 @@ type selectOption interface {
 
 var txCounter uint64
 
+// logger returns the logger of the database.
+func (db *DB) logger() Logger {
+	if db.Logger != nil {
+		return db.Logger
+	}
+
+	return log
+}
+
+// SlowThreshold is the default duration after which transactions and
+// queries are logged as slow, see Tx.SlowThreshold.
+var SlowThreshold = 1 * time.Second
+
+// MaxStatements is the default number of prepared statements cached by a
+// transaction, see Tx.MaxStatements.
+var MaxStatements = 0
+
+// MaxInParams is the number of keys the generated Delete<Type>s deletes per
+// query, to stay under the limit of placeholders of the driver.
+var MaxInParams = 1000
+
 // Begin TODO: NEEDS COMMENT INFO
 func (db *DB) Begin(ctx context.Context, opts ...TxOptionFunc) (*Tx, error) {
 	txOptions := &sql.TxOptions{}
@@ -88,15 +120,17 @@ func (db *DB) Begin(ctx context.Context, opts ...TxOptionFunc) (*Tx, error) {
 		return nil, fmt.Errorf("Error starting transaction: %w", err)
 	}
 
-	trace := make([]byte, 1024)
-	count := runtime.Stack(trace, true)
-	trace = trace[:count]
+	// where the transaction was opened, for the slow transaction warning.
+	trace := debug.Stack()
 
 	counter := atomic.AddUint64(&txCounter, 1)
 
 	id, _ := uuid.NewUUID()
 
-	log.Debugf("[%d] Starting new transaction (%s): %p (%s)", counter, findMethod(), tx, id.String())
+	// finding the method is expensive, only do so when it is logged.
+	if debugEnabled(db.logger()) {
+		db.logger().Debugf("[%d] Starting new transaction (%s): %p (%s)", counter, findMethod(), tx, id.String())
+	}
 
 	return &Tx{
 		Tx: tx,
@@ -107,9 +141,27 @@ func (db *DB) Begin(ctx context.Context, opts ...TxOptionFunc) (*Tx, error) {
 		m:          sync.Mutex{},
 		stacktrace: string(trace),
 		time:       time.Now(),
+
+		Logger:        db.Logger,
+		SlowThreshold: SlowThreshold,
+		MaxStatements: MaxStatements,
+		OnQuery:       db.OnQuery,
 	}, nil
 }
 
+// Begin begins a transaction on the sqlx database, applying the options, eg.
+// db.Begin(ctx, conn, db.ReadOnly()). It is DB.Begin for databases not
+// opened with Connect.
+func Begin(ctx context.Context, db *sqlx.DB, opts ...TxOptionFunc) (*Tx, error) {
+	return (&DB{DB: db}).Begin(ctx, opts...)
+}
+
+// Validator validates an object before the generated Insert, Update and
+// InsertOrUpdate write it, the error of Validate is returned instead.
+type Validator interface {
+	Validate() error
+}
+
 // Updater TODO: NEEDS COMMENT INFO
 type Updater interface {
 	Update(*sqlx.Tx) error
@@ -119,6 +171,18 @@ type InsertOrUpdater interface {
 	InsertOrUpdate(*sqlx.Tx) error
 }
 
+// InsertIgnorer inserts the object, unless it conflicts with an existing
+// row.
+type InsertIgnorer interface {
+	InsertIgnore(*sqlx.Tx) error
+}
+
+// InsertOrUpdateReturner inserts or updates the object and reads the stored
+// row back into it.
+type InsertOrUpdateReturner interface {
+	InsertOrUpdateReturning(*sqlx.Tx) error
+}
+
 // Inserter TODO: NEEDS COMMENT INFO
 type Inserter interface {
 	Insert(*sqlx.Tx) error
@@ -129,6 +193,13 @@ type Selecter interface {
 	Select(*sqlx.Tx, Query, ...interface{}) error
 }
 
+// RowScanner scans the current row of the rows into the object, for types
+// sqlx can't scan, eg. with JSON columns. Selectx scans the rows of a slice
+// of RowScanners through ScanRow.
+type RowScanner interface {
+	ScanRow(rows *sqlx.Rows) error
+}
+
 // Getter TODO: NEEDS COMMENT INFO
 type Getter interface {
 	Get(*sqlx.Tx, Query, []interface{}) error
@@ -138,3 +209,9 @@ type Getter interface {
 type Deleter interface {
 	Delete(*sqlx.Tx) error
 }
+
+// HardDeleter is implemented by types that can permanently remove
+// their row, instead of the (soft) Delete.
+type HardDeleter interface {
+	DeleteHard(*sqlx.Tx) error
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestEachx(t *testing.T) {
+	tx, mock := mockTx(t)
+
+	qy := SelectQuery("alerts").Fields("id")
+
+	// the options wrap the query
+	mock.ExpectPrepare("SELECT id FROM alerts  LIMIT ?").ExpectQuery().WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3))
+
+	ids := []int{}
+	err := tx.Eachx(qy, func(rows *sqlx.Rows) error {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+
+		ids = append(ids, id)
+		return nil
+	}, Limit(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Got: %v\nWant: %v", ids, want)
+	}
+
+	// the error of fn stops the iteration
+	mock.ExpectQuery("SELECT id FROM alerts  LIMIT ?").WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3)).
+		RowsWillBeClosed()
+
+	errStop := errors.New("stop")
+
+	calls := 0
+	err = tx.Eachx(qy, func(rows *sqlx.Rows) error {
+		calls++
+		return errStop
+	}, Limit(3))
+	if err != errStop {
+		t.Errorf("Got: %v\nWant: %v", err, errStop)
+	}
+
+	if calls != 1 {
+		t.Errorf("Got: %d calls\nWant: 1", calls)
+	}
+}
@@ -14,19 +14,30 @@
 package db
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
+	"reflect"
 
 	"github.com/go-sql-driver/mysql"
 )
 
-//  TODO: NEEDS COMMENT INFO
+// TODO: NEEDS COMMENT INFO
 var (
-	ErrNoGetterFound          = errors.New("No Getter found")
-	ErrNoDeleterFound         = errors.New("No Deleter found")
-	ErrNoSelecterFound        = errors.New("No Select found")
-	ErrNoInsertOrUpdaterFound = errors.New("No InsertOrUpdater found")
-	ErrNoUpdaterFound         = errors.New("No Updater found")
-	ErrNoInserterFound        = errors.New("No Inserter found")
+	ErrNoGetterFound                 = errors.New("No Getter found")
+	ErrNoDeleterFound                = errors.New("No Deleter found")
+	ErrNoHardDeleterFound            = errors.New("No HardDeleter found")
+	ErrNoSelecterFound               = errors.New("No Select found")
+	ErrNoInsertOrUpdaterFound        = errors.New("No InsertOrUpdater found")
+	ErrNoUpdaterFound                = errors.New("No Updater found")
+	ErrNoInserterFound               = errors.New("No Inserter found")
+	ErrNotFound                      = errors.New("Not found")
+	ErrStaleObject                   = errors.New("Stale object")
+	ErrSavepointNotFound             = errors.New("No Savepoint found")
+	ErrNoInsertOrUpdateReturnerFound = errors.New("No InsertOrUpdateReturner found")
+	ErrNoInsertIgnorerFound          = errors.New("No InsertIgnorer found")
+	ErrNoSlicePointer                = errors.New("Destination is not a pointer to a slice")
+	ErrExpectedSlice                 = errors.New("Expected a slice, use Getx to select a single row")
 )
 
 func IsDuplicateKeyErr(err error) bool {
@@ -37,3 +48,58 @@ func IsDuplicateKeyErr(err error) bool {
 
 	return merr.Number == 1062
 }
+
+// notFoundError is a sql.ErrNoRows that matches ErrNotFound too, so callers
+// can check errors.Is(err, db.ErrNotFound) for every method.
+type notFoundError struct {
+	err error
+}
+
+func (e *notFoundError) Error() string {
+	return e.err.Error()
+}
+
+func (e *notFoundError) Unwrap() error {
+	return e.err
+}
+
+func (e *notFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// wrapErr returns err annotated with the operation on the object, a
+// sql.ErrNoRows also matches ErrNotFound.
+func wrapErr(op string, o interface{}, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if err == sql.ErrNoRows {
+		err = &notFoundError{err}
+	}
+
+	return fmt.Errorf("%s %s: %w", op, reflect.TypeOf(o), err)
+}
+
+// sqlStater is implemented by the errors of the postgres drivers.
+type sqlStater interface {
+	SQLState() string
+}
+
+// IsRetryableErr reports whether the transaction failed because of a
+// conflict with another transaction and can be retried, wrapped errors are
+// unwrapped. These are deadlocks and lock wait timeouts for MySQL,
+// serialization failures and deadlocks for postgres.
+func IsRetryableErr(err error) bool {
+	var merr *mysql.MySQLError
+	if errors.As(err, &merr) {
+		return merr.Number == 1213 || merr.Number == 1205
+	}
+
+	var serr sqlStater
+	if errors.As(err, &serr) {
+		return serr.SQLState() == "40001" || serr.SQLState() == "40P01"
+	}
+
+	return false
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+type sqlStateErr string
+
+func (e sqlStateErr) Error() string    { return "pq: " + string(e) }
+func (e sqlStateErr) SQLState() string { return string(e) }
+
+func TestIsRetryableErr(t *testing.T) {
+	retryable := []error{
+		&mysql.MySQLError{Number: 1213},
+		&mysql.MySQLError{Number: 1205},
+		fmt.Errorf("Could not commit transaction: %w", &mysql.MySQLError{Number: 1213}),
+		sqlStateErr("40001"),
+		sqlStateErr("40P01"),
+	}
+
+	for _, err := range retryable {
+		if !IsRetryableErr(err) {
+			t.Errorf("Got: not retryable\nWant: retryable %v", err)
+		}
+	}
+
+	for _, err := range []error{nil, errors.New("deadlock"), &mysql.MySQLError{Number: 1062}, sqlStateErr("23505")} {
+		if IsRetryableErr(err) {
+			t.Errorf("Got: retryable\nWant: not retryable %v", err)
+		}
+	}
+}
+
+type fakeUpdater struct {
+	err error
+}
+
+func (u *fakeUpdater) Update(tx *sqlx.Tx) error {
+	return u.err
+}
+
+func TestWrapErr(t *testing.T) {
+	tx := &Tx{}
+
+	err := tx.Update(&fakeUpdater{sql.ErrNoRows})
+	if !errors.Is(err, ErrNotFound) || !IsNoRowsErr(err) {
+		t.Errorf("Got: %v\nWant: an error matching ErrNotFound and sql.ErrNoRows", err)
+	}
+
+	if want := "update *db.fakeUpdater: sql: no rows in result set"; err.Error() != want {
+		t.Errorf("Got: %s\nWant: %s", err, want)
+	}
+
+	if err := tx.Update(&fakeUpdater{ErrStaleObject}); !errors.Is(err, ErrStaleObject) || errors.Is(err, ErrNotFound) {
+		t.Errorf("Got: %v\nWant: an error matching only ErrStaleObject", err)
+	}
+
+	if err := tx.Update(&fakeUpdater{}); err != nil {
+		t.Errorf("Got: %v\nWant: no error", err)
+	}
+
+	if err := tx.Update(struct{}{}); err != ErrNoUpdaterFound {
+		t.Errorf("Got: %v\nWant: %v", err, ErrNoUpdaterFound)
+	}
+}
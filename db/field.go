@@ -21,8 +21,20 @@ import (
 
 type Field string
 
-func (s Field) Alias(alias string) {
-	// NOT IMPLEMENTED YET
+// Alias returns the field selected as alias, see As.
+func (s Field) Alias(alias string) Field {
+	return As(s, alias)
+}
+
+// As returns the field selecting the expression as alias, eg.
+// Fields(db.As("LOWER(email)", "email"), db.As(db.Count("*"), "total")).
+// The expression is used as is, it panics on invalid aliases.
+func As(expr Field, alias string) Field {
+	if _, err := sanitize(alias); err != nil {
+		panic(fmt.Sprintf("db: invalid alias: %s", err))
+	}
+
+	return Field(fmt.Sprintf("%s AS %s", expr, alias))
 }
 
 func sanitize(s string) (string, error) {
@@ -30,6 +42,10 @@ func sanitize(s string) (string, error) {
 
 	state := 0
 
+	// the quote character that opened the current identifier, either
+	// a backtick (mysql) or a double quote (postgres)
+	quote := rune(0)
+
 	// we need to tokenize
 
 	i := 0
@@ -42,6 +58,11 @@ func sanitize(s string) (string, error) {
 
 	for i < len(runes) {
 		switch {
+		case state == 2 && runes[i] != quote:
+			// quoted identifiers hold any character but their quote,
+			// eg. `audit.by` of a prefixed embedded struct
+			field += string(runes[i])
+			i++
 		case valid(runes[i]):
 			if state == 0 {
 				field += string(runes[i])
@@ -57,13 +78,15 @@ func sanitize(s string) (string, error) {
 			} else {
 				return "", fmt.Errorf("Warning, unexpected character in field name: %c (%s)", runes[i], s)
 			}
-		case runes[i] == '`':
+		case runes[i] == '`' || runes[i] == '"':
 			if state == 0 {
+				quote = runes[i]
+
 				field += string(runes[i])
 				i++
 
 				state = 2
-			} else if state == 2 {
+			} else if state == 2 && runes[i] == quote {
 				field += string(runes[i])
 				i++
 
@@ -15,8 +15,21 @@ package db
 
 type groupBy []Field
 
+// GroupBy groups the selected rows by the fields, eg.
+// Fields(AlertStatus, db.Count("*")).GroupBy(AlertStatus).
 func (tq Queryx) GroupBy(fields ...Field) Queryx {
 	gb := groupBy(fields)
 	tq.builder = append(tq.builder, gb)
 	return tq
 }
+
+// grouped reports whether the query groups its rows.
+func (tq Queryx) grouped() bool {
+	for _, expr := range tq.builder {
+		if _, ok := expr.(groupBy); ok {
+			return true
+		}
+	}
+
+	return false
+}
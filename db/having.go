@@ -0,0 +1,52 @@
+// Copyright 2019 The DutchSec Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package db
+
+import "strings"
+
+type having struct {
+	cond   string
+	params []interface{}
+}
+
+// Having filters the groups of a grouped query on the condition, eg.
+// GroupBy(AlertStatus).Having("COUNT(*) > ?", 5). The conditions of
+// several calls all have to match.
+func (tq Queryx) Having(cond string, params ...interface{}) Queryx {
+	builder := make([]interface{}, len(tq.builder), len(tq.builder)+1)
+	copy(builder, tq.builder)
+
+	tq.builder = append(builder, having{cond, params})
+	return tq
+}
+
+// buildHaving returns the HAVING clause of the query and its parameters,
+// an empty clause when the query has no conditions on its groups.
+func (tq Queryx) buildHaving() (string, []interface{}) {
+	conds := []string{}
+	params := []interface{}{}
+
+	for _, expr := range tq.builder {
+		if h, ok := expr.(having); ok {
+			conds = append(conds, "("+h.cond+")")
+			params = append(params, h.params...)
+		}
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+
+	return "HAVING " + strings.Join(conds, " AND ") + " ", params
+}
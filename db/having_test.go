@@ -0,0 +1,47 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHaving(t *testing.T) {
+	q := SelectQuery("alerts").Fields("status", Count("*")).
+		Where(Compare("active", "=", 1)).
+		GroupBy("status").
+		Having("COUNT(*) > ?", 5).
+		Having("MAX(created_at) > ?", "2019-01-01")
+
+	got, params := q.Build()
+
+	want := Query("SELECT status,COUNT(*) FROM alerts WHERE active = ? GROUP BY status HAVING (COUNT(*) > ?) AND (MAX(created_at) > ?) ")
+	if got != want {
+		t.Errorf("Got: %q\nWant: %q", got, want)
+	}
+
+	if want := []interface{}{1, 5, "2019-01-01"}; !reflect.DeepEqual(params, want) {
+		t.Errorf("Got: %v\nWant: %v", params, want)
+	}
+}
+
+func TestTotalQuery(t *testing.T) {
+	q := SelectQuery("alerts").Fields("id", "name").Where(Compare("active", "=", 1)).OrderBy("name").Limit(0, 10)
+
+	got, params := countQuery(totalQuery(q))
+
+	if want := Query("SELECT COUNT(*) FROM alerts WHERE active = ? "); got != want {
+		t.Errorf("Got: %q\nWant: %q", got, want)
+	}
+
+	if want := []interface{}{1}; !reflect.DeepEqual(params, want) {
+		t.Errorf("Got: %v\nWant: %v", params, want)
+	}
+
+	grouped := SelectQuery("alerts").Fields("status", Count("*")).GroupBy("status").Having("COUNT(*) > ?", 5)
+
+	got, _ = countQuery(totalQuery(grouped))
+
+	if want := Query("SELECT COUNT(*) FROM (SELECT status,COUNT(*) FROM alerts GROUP BY status HAVING (COUNT(*) > ?) ) q"); got != want {
+		t.Errorf("Got: %q\nWant: %q", got, want)
+	}
+}
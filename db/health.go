@@ -0,0 +1,38 @@
+// Copyright 2019 The DutchSec Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Ping verifies the connection to the database, eg. for a health check
+// endpoint. A connection is opened when the pool has none.
+func Ping(ctx context.Context, db *sqlx.DB) error {
+	if err := db.PingContext(ctx); err != nil {
+		log.Errorf("Error pinging the database: %s", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Stats returns the statistics of the connection pool of the database, eg.
+// the open and in use connections for a health check endpoint.
+func Stats(db *sqlx.DB) sql.DBStats {
+	return db.Stats()
+}
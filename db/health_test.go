@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func TestPing(t *testing.T) {
+	d := &recordDriver{}
+	sql.Register("ping", d)
+
+	conn, err := sqlx.Open("ping", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := Stats(conn).OpenConnections; got != 0 {
+		t.Errorf("Got: %d open connections\nWant: 0", got)
+	}
+
+	if err := Ping(context.Background(), conn); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := Stats(conn).OpenConnections; got != 1 {
+		t.Errorf("Got: %d open connections\nWant: 1", got)
+	}
+
+	conn.Close()
+
+	if err := Ping(context.Background(), conn); err == nil {
+		t.Errorf("Got: no error\nWant: error for a closed database")
+	}
+}
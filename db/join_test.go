@@ -26,3 +26,20 @@ func TestJoin(t *testing.T) {
 		}
 	}
 }
+
+func TestJoinBeforeWhere(t *testing.T) {
+	q := SelectQuery("alerts").Fields("*").
+		Where(Compare("alerts.status", "=", 1)).
+		LeftJoin("users").On(Equal(Field("alerts.user_id"), Field("users.id")))
+
+	got, params := q.Build()
+
+	want := Query("SELECT * FROM alerts LEFT JOIN users ON alerts.user_id = users.id WHERE alerts.status = ? ")
+	if got != want {
+		t.Errorf("Got: %s\nWant: %s", got, want)
+	}
+
+	if len(params) != 1 || params[0] != 1 {
+		t.Errorf("Got params: %v\nWant: [1]", params)
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2019 The DutchSec Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package db
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// JSON stores the value V points to as JSON, for columns holding JSON
+// documents, eg. db.JSON{V: &s.Payload}.
+type JSON struct {
+	V interface{}
+}
+
+// Value encodes the value as JSON.
+func (j JSON) Value() (driver.Value, error) {
+	b, err := json.Marshal(j.V)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+// Scan decodes the JSON column into the value, NULL leaves it unchanged.
+func (j *JSON) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, j.V)
+	case string:
+		return json.Unmarshal([]byte(v), j.V)
+	default:
+		return fmt.Errorf("Unsupported type %T for JSON column", src)
+	}
+}
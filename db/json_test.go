@@ -0,0 +1,28 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	in := map[string]interface{}{"name": "disk", "level": 2.0}
+
+	v, err := JSON{V: &in}.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := map[string]interface{}{}
+	if err := (&JSON{V: &out}).Scan([]byte(v.(string))); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("Got: %v\nWant: %v", out, in)
+	}
+
+	if err := (&JSON{V: &out}).Scan(nil); err != nil {
+		t.Errorf("Got: %s\nWant: no error for NULL", err)
+	}
+}
@@ -13,32 +13,40 @@
 // limitations under the License.
 package db
 
-/*
-func (qx Queryx) Limit(offset, count int) Queryx {
-	q := string(qx.Query)
-	params := qx.Params
+import "fmt"
 
-	q, params = Limit(offset, count).Wrap(q, params)
+// Limit returns a select option that limits the number of rows returned.
+func Limit(count int) selectOption {
+	return &limitCountOption{count}
+}
+
+type limitCountOption struct {
+	count int
+}
 
-	return Queryx{
-		Query(q),
-		params,
-	}
+// Wrap appends the LIMIT clause to the query.
+func (o *limitCountOption) Wrap(query string, params []interface{}) (string, []interface{}) {
+	query = fmt.Sprintf("%s LIMIT ?", query)
+	params = append(params, o.count)
+	return query, params
 }
 
-// Limit TODO: NEEDS COMMENT INFO
-func Limit(offset, count int) selectOption {
-	return &limitOption{offset, count}
+// Offset returns a select option that skips the first rows. Offset must be
+// passed after Limit, eg. tx.Selectx(&rows, q, db.Limit(20), db.Offset(40)).
+func Offset(offset int) selectOption {
+	return &offsetOption{offset}
 }
 
-// Wrap TODO: NEEDS COMMENT INFO
-func (o *limitOption) Wrap(query string, params []interface{}) (string, []interface{}) {
-	query = fmt.Sprintf("%s LIMIT ?, ?", query)
+type offsetOption struct {
+	offset int
+}
+
+// Wrap appends the OFFSET clause to the query.
+func (o *offsetOption) Wrap(query string, params []interface{}) (string, []interface{}) {
+	query = fmt.Sprintf("%s OFFSET ?", query)
 	params = append(params, o.offset)
-	params = append(params, o.count)
 	return query, params
 }
-*/
 
 type limitOption struct {
 	offset int
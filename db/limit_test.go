@@ -0,0 +1,23 @@
+package db
+
+import (
+	"testing"
+)
+
+func TestLimitOffset(t *testing.T) {
+	q, params := SelectQuery("TABLE").Fields("*").Build()
+
+	query := string(q)
+	for _, option := range []selectOption{Limit(20), Offset(40)} {
+		query, params = option.Wrap(query, params)
+	}
+
+	want := "SELECT * FROM TABLE  LIMIT ? OFFSET ?"
+	if query != want {
+		t.Errorf("Got: %s\nWant: %s", query, want)
+	}
+
+	if len(params) != 2 || params[0] != 20 || params[1] != 40 {
+		t.Errorf("Got params: %v\nWant: [20 40]", params)
+	}
+}
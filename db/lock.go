@@ -0,0 +1,36 @@
+// Copyright 2019 The DutchSec Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package db
+
+// ForUpdate returns a select option locking the selected rows for update
+// until the transaction ends, eg.
+// tx.Selectx(&alerts, QueryAlerts().Where(db.Equal(AlertID, id)), db.ForUpdate()).
+func ForUpdate() selectOption {
+	return &lockOption{"FOR UPDATE"}
+}
+
+// ForShare returns a select option locking the selected rows against
+// updates by other transactions until the transaction ends.
+func ForShare() selectOption {
+	return &lockOption{"FOR SHARE"}
+}
+
+type lockOption struct {
+	clause string
+}
+
+// Wrap appends the locking clause to the query.
+func (o *lockOption) Wrap(query string, params []interface{}) (string, []interface{}) {
+	return query + " " + o.clause, params
+}
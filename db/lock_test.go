@@ -0,0 +1,26 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestForUpdate(t *testing.T) {
+	q, params := SelectQuery("alerts").Fields("id").Build()
+
+	got, params := wrapQuery(q, params, []selectOption{ForUpdate(), Limit(10), Offset(20)})
+
+	if want := Query("SELECT id FROM alerts  LIMIT ? OFFSET ? FOR UPDATE"); got != want {
+		t.Errorf("Got: %q\nWant: %q", got, want)
+	}
+
+	if want := []interface{}{10, 20}; !reflect.DeepEqual(params, want) {
+		t.Errorf("Got: %v\nWant: %v", params, want)
+	}
+
+	got, _ = wrapQuery(q, nil, []selectOption{ForShare()})
+
+	if want := Query("SELECT id FROM alerts  FOR SHARE"); got != want {
+		t.Errorf("Got: %q\nWant: %q", got, want)
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2019 The DutchSec Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package db
+
+import (
+	logging "github.com/op/go-logging"
+)
+
+// Logger logs the transactions and queries, its methods match those of
+// github.com/op/go-logging. Set it with SetLogger, DB.Logger or Tx.Logger.
+// Beginning and committing a transaction is logged at debug level, a slow
+// commit as a warning. Loggers with an IsEnabledFor(logging.Level) method
+// skip the debug messages of transactions without formatting them.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warningf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// SetLogger sets the default logger of the package, used by databases and
+// transactions without a logger of their own.
+func SetLogger(l Logger) {
+	log = l
+}
+
+// debugEnabled reports whether the logger logs debug messages, loggers
+// that can't tell are assumed to do so.
+func debugEnabled(l Logger) bool {
+	if l, ok := l.(interface {
+		IsEnabledFor(logging.Level) bool
+	}); ok {
+		return l.IsEnabledFor(logging.DEBUG)
+	}
+
+	return true
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	logging "github.com/op/go-logging"
+)
+
+type recordLogger struct {
+	lines []string
+}
+
+func (l *recordLogger) Debugf(format string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func (l *recordLogger) Infof(format string, args ...interface{})    { l.Debugf(format, args...) }
+func (l *recordLogger) Warningf(format string, args ...interface{}) { l.Debugf(format, args...) }
+func (l *recordLogger) Errorf(format string, args ...interface{})   { l.Debugf(format, args...) }
+
+func TestTxLogger(t *testing.T) {
+	tx := &Tx{}
+	if tx.log() != log {
+		t.Errorf("Got: %v\nWant: the package logger", tx.log())
+	}
+
+	l := &recordLogger{}
+
+	tx.Logger = l
+	if err := tx.Savepoint("sp"); err == nil {
+		t.Fatalf("Got: no error\nWant: error for a finished transaction")
+	}
+
+	tx.log().Infof("[%d] test", 1)
+	if len(l.lines) != 1 || l.lines[0] != "[1] test" {
+		t.Errorf("Got: %v\nWant: [[1] test]", l.lines)
+	}
+
+	if !debugEnabled(l) {
+		t.Errorf("Got: debug disabled\nWant: enabled for loggers without levels")
+	}
+}
+
+// quietLogger is a logger with debug disabled.
+type quietLogger struct {
+	recordLogger
+}
+
+func (l *quietLogger) IsEnabledFor(level logging.Level) bool {
+	return level != logging.DEBUG
+}
+
+func TestCommitQuiet(t *testing.T) {
+	sql.Register("quiet", &recordDriver{})
+
+	conn, err := sqlx.Open("quiet", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := &quietLogger{}
+
+	tx, err := (&DB{DB: conn, Logger: l}).Begin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(l.lines) != 0 {
+		t.Errorf("Got: %v\nWant: no messages with debug disabled", l.lines)
+	}
+}
@@ -24,10 +24,14 @@ func Active() Operator {
 	return &equalOperator{"active", "1"}
 }
 
+// True matches the rows where the boolean field is true. The value is
+// bound as a bool, which the drivers convert to 1 on MySQL and SQLite and
+// to TRUE on postgres.
 func True(fld Field) Operator {
-	return &equalOperator{fld, "1"}
+	return &equalOperator{fld, true}
 }
 
+// False matches the rows where the boolean field is false.
 func False(fld Field) Operator {
-	return &equalOperator{fld, "0"}
+	return &equalOperator{fld, false}
 }
@@ -13,6 +13,11 @@
 // limitations under the License.
 package db
 
+import (
+	"fmt"
+	"strings"
+)
+
 type orderByOption struct {
 	fields []Field
 	desc   bool
@@ -32,3 +37,31 @@ func (tq Queryx) OrderByDesc(fields ...Field) Queryx {
 	tq.builder = append(tq.builder, ob)
 	return tq
 }
+
+// OrderBy returns a select option that orders the rows by the field, in
+// direction ASC or DESC. It panics on other directions or invalid field
+// names. OrderBy must be passed before Limit and Offset, eg.
+// tx.Selectx(&rows, q, db.OrderBy(AlertCreatedAt, "DESC"), db.Limit(20)).
+func OrderBy(field Field, dir string) selectOption {
+	dir = strings.ToUpper(dir)
+	if dir != "ASC" && dir != "DESC" {
+		panic(fmt.Sprintf("db: invalid order direction %q", dir))
+	}
+
+	if _, err := sanitize(string(field)); err != nil {
+		panic(fmt.Sprintf("db: invalid order field: %s", err))
+	}
+
+	return &orderByFieldOption{field, dir}
+}
+
+type orderByFieldOption struct {
+	field Field
+	dir   string
+}
+
+// Wrap appends the ORDER BY clause to the query.
+func (o *orderByFieldOption) Wrap(query string, params []interface{}) (string, []interface{}) {
+	query = fmt.Sprintf("%s ORDER BY %s %s", query, o.field, o.dir)
+	return query, params
+}
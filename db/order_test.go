@@ -28,3 +28,55 @@ func TestOrder(t *testing.T) {
 		}
 	}
 }
+
+func TestOrderByOption(t *testing.T) {
+	q, params := SelectQuery("TABLE").Fields("*").Build()
+
+	query := string(q)
+	for _, option := range []selectOption{OrderBy("TABLE.created_at", "desc"), Limit(20)} {
+		query, params = option.Wrap(query, params)
+	}
+
+	want := "SELECT * FROM TABLE  ORDER BY TABLE.created_at DESC LIMIT ?"
+	if query != want {
+		t.Errorf("Got: %s\nWant: %s", query, want)
+	}
+
+	if len(params) != 1 || params[0] != 20 {
+		t.Errorf("Got params: %v\nWant: [20]", params)
+	}
+}
+
+func TestOrderByOptionInvalid(t *testing.T) {
+	for _, o := range [][2]string{{"created_at", "DESC; DROP TABLE x"}, {"created_at; DROP TABLE x", "ASC"}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Got: no panic\nWant: panic for OrderBy(%q, %q)", o[0], o[1])
+				}
+			}()
+
+			OrderBy(Field(o[0]), o[1])
+		}()
+	}
+}
+
+func TestOrderByPrefixedField(t *testing.T) {
+	// the columns of embedded structs tagged with a prefix hold a dot
+	field := Field("`alerts`.`audit.by`")
+
+	q, params := SelectQuery("`alerts`").Fields(field).Build()
+
+	query := string(q)
+	query, _ = OrderBy(field, "ASC").Wrap(query, params)
+
+	if want := "SELECT `alerts`.`audit.by` FROM `alerts`  ORDER BY `alerts`.`audit.by` ASC"; query != want {
+		t.Errorf("Got: %q\nWant: %q", query, want)
+	}
+
+	for _, name := range []string{"`alerts`.`audit.by", "`audit`by`", "alerts.audit by"} {
+		if _, err := sanitize(name); err == nil {
+			t.Errorf("Got: no error\nWant: error for %s", name)
+		}
+	}
+}
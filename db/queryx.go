@@ -21,6 +21,10 @@ import (
 type Queryx struct {
 	tableName string
 
+	// from is the table selected from instead of tableName, which is its
+	// alias, see Table.
+	from string
+
 	type_ string
 
 	countRows bool
@@ -28,6 +32,13 @@ type Queryx struct {
 	fields []Field
 
 	builder []interface{}
+
+	// notDeleted filters out the soft deleted rows.
+	notDeleted Operator
+
+	// raw is the query of RawQuery, it is used as is.
+	raw       Query
+	rawParams []interface{}
 }
 
 func (tq Queryx) Dump() string {
@@ -47,6 +58,14 @@ func (tq Queryx) CountRows() Queryx {
 */
 
 func (tq Queryx) Build() (Query, []interface{}) {
+	if tq.raw != "" {
+		return tq.raw, tq.rawParams
+	}
+
+	if tq.notDeleted != nil {
+		tq = tq.And(tq.notDeleted)
+	}
+
 	fields := make([]string, len(tq.fields))
 	for i, field := range tq.fields {
 		fields[i] = string(field)
@@ -73,26 +92,20 @@ func (tq Queryx) Build() (Query, []interface{}) {
 
 	b.WriteString("FROM ")
 
-	b.WriteString(fmt.Sprintf("%s ", tq.tableName))
+	if tq.from != "" {
+		b.WriteString(fmt.Sprintf("%s %s ", tq.from, unqualified(tq.tableName)))
+	} else {
+		b.WriteString(fmt.Sprintf("%s ", tq.tableName))
+	}
 
 	params := []interface{}{}
 
 	orderByOptions := []orderByOption{}
 
+	// the joins go between the FROM and the WHERE, regardless of the
+	// order they were added in.
 	for _, expr := range tq.builder {
-		// b.WriteString(expr.String())
-		if w, ok := expr.(where); ok {
-
-			whereStmt, whereParams := w.Make()
-			params = append(params, whereParams...)
-
-			if whereStmt == "" {
-			} else {
-				b.WriteString("WHERE ")
-				b.WriteString(whereStmt)
-				b.WriteString(" ")
-			}
-		} else if tjq, ok := expr.(tableJoinQuery); ok {
+		if tjq, ok := expr.(tableJoinQuery); ok {
 			if tjq.joinType == "LEFT" {
 				b.WriteString("LEFT JOIN ")
 			} else if tjq.joinType == "RIGHT" {
@@ -116,7 +129,31 @@ func (tq Queryx) Build() (Query, []interface{}) {
 				b.WriteString(whereStmt)
 				b.WriteString(" ")
 			}
-		} else if gb, ok := expr.(groupBy); ok {
+		}
+	}
+
+	for _, expr := range tq.builder {
+		// b.WriteString(expr.String())
+		if w, ok := expr.(where); ok {
+
+			whereStmt, whereParams := w.Make()
+			params = append(params, whereParams...)
+
+			if whereStmt == "" {
+			} else {
+				b.WriteString("WHERE ")
+				b.WriteString(whereStmt)
+				b.WriteString(" ")
+			}
+		} else if ob, ok := expr.(orderByOption); ok {
+			orderByOptions = append(orderByOptions, ob)
+		}
+	}
+
+	// the group by follows the where, regardless of the order they were
+	// added in.
+	for _, expr := range tq.builder {
+		if gb, ok := expr.(groupBy); ok {
 			b.WriteString("GROUP BY ")
 
 			fields := make([]string, len(gb))
@@ -124,13 +161,14 @@ func (tq Queryx) Build() (Query, []interface{}) {
 				fields[i] = string(field)
 			}
 
-			// TODO: join fields
 			b.WriteString(fmt.Sprintf("%s ", strings.Join(fields, ",")))
-		} else if ob, ok := expr.(orderByOption); ok {
-			orderByOptions = append(orderByOptions, ob)
 		}
 	}
 
+	havingStmt, havingParams := tq.buildHaving()
+	b.WriteString(havingStmt)
+	params = append(params, havingParams...)
+
 	if len(orderByOptions) > 0 {
 		tempStrs := []string{}
 
@@ -160,9 +198,25 @@ func (tq Queryx) Build() (Query, []interface{}) {
 
 	qry := b.String()
 
+	// a schema qualified table is aliased by its bare name, so are the
+	// fields referring to it
+	if alias := unqualified(tq.tableName); tq.from != "" && alias != tq.tableName {
+		qry = strings.Replace(qry, tq.tableName+".", alias+".", -1)
+	}
+
 	return Query(qry), params
 }
 
+// unqualified returns the table name without its schema, eg. `alerts` for
+// `analytics`.`alerts`.
+func unqualified(tableName string) string {
+	if i := strings.LastIndex(tableName, "."); i >= 0 {
+		return tableName[i+1:]
+	}
+
+	return tableName
+}
+
 func SelectQuery(tableName string) Queryx {
 	return Queryx{
 		tableName: tableName,
@@ -170,6 +224,16 @@ func SelectQuery(tableName string) Queryx {
 	}
 }
 
+// RawQuery returns a query executing q with the parameters as is, for
+// statements the builder can't express, eg.
+// db.RawQuery("INSERT INTO alerts (name) VALUES (?) RETURNING id", name).
+func RawQuery(q Query, params ...interface{}) Queryx {
+	return Queryx{
+		raw:       q,
+		rawParams: params,
+	}
+}
+
 func DeleteQuery(tableName string) Queryx {
 	return Queryx{
 		tableName: tableName,
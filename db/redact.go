@@ -0,0 +1,78 @@
+// Copyright 2019 The DutchSec Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package db
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+// LogParams formats the parameters of queries for the debug log, queries
+// themselves are logged with placeholders only. When nil, the default,
+// parameters are not logged. Use HashParam or TruncateParam to keep
+// personal data out of the logs.
+var LogParams func(param interface{}) string
+
+// HashParam formats string parameters as the start of their SHA-256 hash,
+// equal values can be recognized without logging them.
+func HashParam(param interface{}) string {
+	switch v := param.(type) {
+	case string:
+		return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(v)))[:19]
+	case []byte:
+		return fmt.Sprintf("sha256:%x", sha256.Sum256(v))[:19]
+	default:
+		return fmt.Sprint(param)
+	}
+}
+
+// TruncateParam returns a formatter logging the first n characters of
+// string parameters.
+func TruncateParam(n int) func(param interface{}) string {
+	return func(param interface{}) string {
+		switch v := param.(type) {
+		case string:
+			return truncate(v, n)
+		case []byte:
+			return truncate(string(v), n)
+		default:
+			return fmt.Sprint(param)
+		}
+	}
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+
+	return string(runes[:n]) + "..."
+}
+
+// formatParams returns the parameters formatted for the log, or an empty
+// string when parameters aren't logged.
+func formatParams(params []interface{}) string {
+	if LogParams == nil || len(params) == 0 {
+		return ""
+	}
+
+	formatted := make([]string, len(params))
+	for i, param := range params {
+		formatted[i] = LogParams(param)
+	}
+
+	return fmt.Sprintf(" [%s]", strings.Join(formatted, ", "))
+}
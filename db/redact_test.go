@@ -0,0 +1,31 @@
+package db
+
+import (
+	"testing"
+)
+
+func TestFormatParams(t *testing.T) {
+	defer func() {
+		LogParams = nil
+	}()
+
+	params := []interface{}{"jane@example.com", 42}
+
+	if got := formatParams(params); got != "" {
+		t.Errorf("Got: %s\nWant: no params", got)
+	}
+
+	LogParams = TruncateParam(4)
+
+	want := " [jane..., 42]"
+	if got := formatParams(params); got != want {
+		t.Errorf("Got: %s\nWant: %s", got, want)
+	}
+
+	LogParams = HashParam
+
+	want = " [sha256:8c87b489ce35, 42]"
+	if got := formatParams(params); got != want {
+		t.Errorf("Got: %s\nWant: %s", got, want)
+	}
+}
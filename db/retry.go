@@ -0,0 +1,48 @@
+// Copyright 2019 The DutchSec Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// retryBackoff is the wait before the first retry, it doubles for every
+// next retry.
+var retryBackoff = 10 * time.Millisecond
+
+// WithRetry runs fn in a transaction and commits it. When the transaction
+// fails with a retryable error (see IsRetryableErr) it is rolled back and
+// retried, at most n times. Other errors are returned after rolling back,
+// fn shouldn't commit or roll back the transaction itself.
+func WithRetry(ctx context.Context, db *DB, n int, fn func(*Tx) error) error {
+	backoff := retryBackoff
+
+	for i := 0; ; i++ {
+		err := runTx(ctx, db, fn)
+		if err == nil || i >= n || !IsRetryableErr(err) {
+			return err
+		}
+
+		db.logger().Warningf("Retrying transaction (%d/%d): %s", i+1, n, err.Error())
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		backoff *= 2
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2019 The DutchSec Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"unicode"
+)
+
+// validSavepoint reports whether the name can be used as savepoint, names
+// are not quoted so only letters, digits and underscores are allowed.
+func validSavepoint(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// savepointIndex returns the position of the named savepoint on the stack,
+// or -1 when it doesn't exist.
+// +checklocks:tx.m
+func (tx *Tx) savepointIndex(name string) int {
+	for i := len(tx.savepoints) - 1; i >= 0; i-- {
+		if tx.savepoints[i] == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Savepoint marks the current state of the transaction, RollbackTo undoes
+// the work done after the savepoint without rolling back the transaction.
+// Savepoints nest, Commit and Rollback end all of them.
+func (tx *Tx) Savepoint(name string) error {
+	tx.m.Lock()
+	defer tx.m.Unlock()
+
+	if tx.Tx == nil {
+		return sql.ErrTxDone
+	}
+
+	if !validSavepoint(name) {
+		return fmt.Errorf("Invalid savepoint name: %q", name)
+	}
+
+	tx.log().Debugf("[%d] Savepoint %s (depth %d)", tx.counter, name, len(tx.savepoints)+1)
+
+	if _, err := tx.Tx.Exec(fmt.Sprintf("SAVEPOINT %s", name)); err != nil {
+		return err
+	}
+
+	tx.savepoints = append(tx.savepoints, name)
+	return nil
+}
+
+// RollbackTo undoes the work done after the named savepoint. The savepoint
+// itself is kept, savepoints created after it are removed.
+func (tx *Tx) RollbackTo(name string) error {
+	tx.m.Lock()
+	defer tx.m.Unlock()
+
+	if tx.Tx == nil {
+		return sql.ErrTxDone
+	}
+
+	i := tx.savepointIndex(name)
+	if i < 0 {
+		return ErrSavepointNotFound
+	}
+
+	tx.log().Debugf("[%d] Rollback to savepoint %s (depth %d)", tx.counter, name, i+1)
+
+	if _, err := tx.Tx.Exec(fmt.Sprintf("ROLLBACK TO SAVEPOINT %s", name)); err != nil {
+		return err
+	}
+
+	tx.savepoints = tx.savepoints[:i+1]
+	return nil
+}
+
+// ReleaseSavepoint removes the named savepoint and the savepoints created
+// after it, keeping the work done since.
+func (tx *Tx) ReleaseSavepoint(name string) error {
+	tx.m.Lock()
+	defer tx.m.Unlock()
+
+	if tx.Tx == nil {
+		return sql.ErrTxDone
+	}
+
+	i := tx.savepointIndex(name)
+	if i < 0 {
+		return ErrSavepointNotFound
+	}
+
+	tx.log().Debugf("[%d] Release savepoint %s (depth %d)", tx.counter, name, i+1)
+
+	if _, err := tx.Tx.Exec(fmt.Sprintf("RELEASE SAVEPOINT %s", name)); err != nil {
+		return err
+	}
+
+	tx.savepoints = tx.savepoints[:i]
+	return nil
+}
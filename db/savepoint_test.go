@@ -0,0 +1,19 @@
+package db
+
+import (
+	"testing"
+)
+
+func TestValidSavepoint(t *testing.T) {
+	for _, name := range []string{"sp1", "import_rows"} {
+		if !validSavepoint(name) {
+			t.Errorf("Got: invalid\nWant: valid savepoint %q", name)
+		}
+	}
+
+	for _, name := range []string{"", "sp 1", "sp;DROP TABLE x", "`sp`"} {
+		if validSavepoint(name) {
+			t.Errorf("Got: valid\nWant: invalid savepoint %q", name)
+		}
+	}
+}
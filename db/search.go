@@ -0,0 +1,102 @@
+// Copyright 2019 The DutchSec Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// likeEscaper escapes the wildcards of LIKE patterns with !, the escape
+// character of the condition. SQLite has no default escape character, and
+// a backslash is quoted differently by MySQL and postgres.
+var likeEscaper = strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`)
+
+// Search returns a select option that filters the rows on the field
+// containing the term, wildcards in the term are matched literally. The
+// condition is added to the where of the query, eg.
+// tx.Selectx(&rows, q, db.Search(AlertName, "disk"), db.Limit(20)).
+// It panics on invalid field names.
+func Search(field Field, term string) selectOption {
+	if _, err := sanitize(string(field)); err != nil {
+		panic(fmt.Sprintf("db: invalid search field: %s", err))
+	}
+
+	return &searchOption{field, term}
+}
+
+type searchOption struct {
+	field Field
+	term  string
+}
+
+// Wrap adds the LIKE condition to the where of the query, before the
+// clauses following it.
+func (o *searchOption) Wrap(query string, params []interface{}) (string, []interface{}) {
+	cond := fmt.Sprintf("%s LIKE ? ESCAPE '!'", o.field)
+	return addCondition(query, params, cond, "%"+likeEscaper.Replace(o.term)+"%")
+}
+
+// addCondition adds the condition and its parameters to the where of the
+// query, before the clauses following it.
+func addCondition(query string, params []interface{}, cond string, args ...interface{}) (string, []interface{}) {
+	end := clauseIndex(query, "GROUP BY ", "ORDER BY ", "LIMIT ", "OFFSET ")
+
+	head := strings.TrimRight(query[:end], " ")
+	if where := clauseIndex(head, "WHERE "); where < len(head) {
+		// the existing condition may contain an OR
+		start := where + len("WHERE ")
+		head = fmt.Sprintf("%s(%s) AND %s", head[:start], strings.TrimSpace(head[start:]), cond)
+	} else {
+		head = fmt.Sprintf("%s WHERE %s", head, cond)
+	}
+
+	// the parameters go after the parameters of the preceding
+	// placeholders.
+	n := strings.Count(query[:end], "?")
+
+	wrapped := make([]interface{}, 0, len(params)+len(args))
+	wrapped = append(wrapped, params[:n]...)
+	wrapped = append(wrapped, args...)
+	wrapped = append(wrapped, params[n:]...)
+
+	return head + " " + query[end:], wrapped
+}
+
+// clauseIndex returns the position of the first of the keywords outside of
+// parentheses in the query, or the length of the query when there is none.
+func clauseIndex(query string, keywords ...string) int {
+	depth := 0
+
+	for i := 0; i < len(query); i++ {
+		switch query[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+
+		if depth != 0 || (i > 0 && query[i-1] != ' ') {
+			continue
+		}
+
+		for _, keyword := range keywords {
+			if strings.HasPrefix(query[i:], keyword) {
+				return i
+			}
+		}
+	}
+
+	return len(query)
+}
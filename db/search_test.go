@@ -0,0 +1,67 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+type SearchSet struct {
+	Query   Queryx
+	Options []selectOption
+	Want    string
+	Params  []interface{}
+}
+
+var (
+	TestSetSearch = []SearchSet{
+		{
+			Query:   SelectQuery("alerts").Fields("*"),
+			Options: []selectOption{Search("name", "disk")},
+			Want:    "SELECT * FROM alerts WHERE name LIKE ? ESCAPE '!' ",
+			Params:  []interface{}{"%disk%"},
+		},
+		{
+			Query:   SelectQuery("alerts").Fields("*"),
+			Options: []selectOption{Search("name", `100%_!`)},
+			Want:    "SELECT * FROM alerts WHERE name LIKE ? ESCAPE '!' ",
+			Params:  []interface{}{`%100!%!_!!%`},
+		},
+		{
+			Query:   SelectQuery("alerts").Fields("*"),
+			Options: []selectOption{Search("name", "disk_1")},
+			Want:    "SELECT * FROM alerts WHERE name LIKE ? ESCAPE '!' ",
+			Params:  []interface{}{"%disk!_1%"},
+		},
+		{
+			Query:   SelectQuery("alerts").Fields("*").Where(Compare("status", "=", 1)).Or(Compare("status", "=", 2)),
+			Options: []selectOption{Search("name", "disk")},
+			Want:    "SELECT * FROM alerts WHERE ((status = ?) OR (status = ?)) AND name LIKE ? ESCAPE '!' ",
+			Params:  []interface{}{1, 2, "%disk%"},
+		},
+		{
+			Query:   SelectQuery("alerts").Fields("*").Where(Compare("status", "=", 1)),
+			Options: []selectOption{OrderBy("name", "ASC"), Limit(20), Search("name", "disk")},
+			Want:    "SELECT * FROM alerts WHERE (status = ?) AND name LIKE ? ESCAPE '!' ORDER BY name ASC LIMIT ?",
+			Params:  []interface{}{1, "%disk%", 20},
+		},
+	}
+)
+
+func TestSearch(t *testing.T) {
+	for _, ts := range TestSetSearch {
+		q, params := ts.Query.Build()
+
+		query := string(q)
+		for _, option := range ts.Options {
+			query, params = option.Wrap(query, params)
+		}
+
+		if query != ts.Want {
+			t.Errorf("Got: %s\nWant: %s", query, ts.Want)
+		}
+
+		if !reflect.DeepEqual(params, ts.Params) {
+			t.Errorf("Got params: %v\nWant: %v", params, ts.Params)
+		}
+	}
+}
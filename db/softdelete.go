@@ -0,0 +1,55 @@
+// Copyright 2019 The DutchSec Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package db
+
+// SoftDelete filters out the soft deleted rows, the rows have to match the
+// operator, eg. db.True(AlertActive). Selects with the IncludeDeleted
+// option return all rows.
+func (tq Queryx) SoftDelete(operator Operator) Queryx {
+	tq.notDeleted = operator
+	return tq
+}
+
+// IncludeDeleted returns the query without the soft delete filter, for
+// queries that are not passed to Selectx, like Countx.
+func (tq Queryx) IncludeDeleted() Queryx {
+	tq.notDeleted = nil
+	return tq
+}
+
+// IncludeDeleted returns a select option that selects the soft deleted
+// rows too, eg. tx.Selectx(&rows, QueryAlerts(), db.IncludeDeleted()).
+func IncludeDeleted() selectOption {
+	return &includeDeletedOption{}
+}
+
+type includeDeletedOption struct{}
+
+// Wrap leaves the query as is, the soft delete filter is removed before
+// the query is built.
+func (o *includeDeletedOption) Wrap(query string, params []interface{}) (string, []interface{}) {
+	return query, params
+}
+
+// withOptions returns the query without the soft delete filter when the
+// options include IncludeDeleted.
+func (tq Queryx) withOptions(options []selectOption) Queryx {
+	for _, option := range options {
+		if _, ok := option.(*includeDeletedOption); ok {
+			return tq.IncludeDeleted()
+		}
+	}
+
+	return tq
+}
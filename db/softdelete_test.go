@@ -0,0 +1,52 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+var (
+	TestSetSoftDelete = []Set{
+		{
+			Query: SelectQuery("alerts").Fields("*").SoftDelete(True("active")),
+			Want:  Query("SELECT * FROM alerts WHERE active = ? "),
+		},
+		{
+			Query: SelectQuery("alerts").Fields("*").SoftDelete(True("active")).Where(Compare("status", "=", 42)),
+			Want:  Query("SELECT * FROM alerts WHERE (status = ?)  AND (active = ?)  "),
+		},
+		{
+			Query: SelectQuery("alerts").Fields("*").SoftDelete(True("active")).IncludeDeleted(),
+			Want:  Query("SELECT * FROM alerts "),
+		},
+		{
+			Query: SelectQuery("alerts").Fields("*").SoftDelete(True("active")).withOptions([]selectOption{Limit(1), IncludeDeleted()}),
+			Want:  Query("SELECT * FROM alerts "),
+		},
+	}
+)
+
+func TestSoftDelete(t *testing.T) {
+	for _, ts := range TestSetSoftDelete {
+		got, _ := ts.Query.Build()
+
+		if got != ts.Want {
+			t.Errorf("Got: %q\nWant: %q", got, ts.Want)
+		}
+	}
+}
+
+func TestSoftDeleteParams(t *testing.T) {
+	// booleans, postgres doesn't compare a boolean column with 1
+	for _, ts := range []struct {
+		Query Queryx
+		Want  []interface{}
+	}{
+		{SelectQuery("alerts").Fields("*").SoftDelete(True("active")), []interface{}{true}},
+		{SelectQuery("alerts").Fields("*").SoftDelete(False("deleted")), []interface{}{false}},
+	} {
+		if _, got := ts.Query.Build(); !reflect.DeepEqual(got, ts.Want) {
+			t.Errorf("Got: %#v\nWant: %#v", got, ts.Want)
+		}
+	}
+}
@@ -13,6 +13,8 @@
 // limitations under the License.
 package db
 
+import "fmt"
+
 type Table string
 
 func (s Table) Alias(alias string) {
@@ -22,3 +24,18 @@ func (s Table) Alias(alias string) {
 func (s Table) Build() (Query, []interface{}) {
 	return Query(s), []interface{}{}
 }
+
+// Table returns the query selecting from the named table instead, eg. a
+// partition with the columns of the generated table:
+// QueryAlerts().Table("alerts_2024"). The generated table name becomes the
+// alias of the table, so the column constants still refer to it. A schema
+// qualified table is aliased without its schema, and so are its fields. It
+// panics on invalid table names.
+func (tq Queryx) Table(name string) Queryx {
+	if _, err := sanitize(name); err != nil {
+		panic(fmt.Sprintf("db: invalid table name: %s", err))
+	}
+
+	tq.from = name
+	return tq
+}
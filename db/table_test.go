@@ -0,0 +1,43 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTable(t *testing.T) {
+	q := SelectQuery("`alerts`").Fields("`alerts`.`id`").Where(Compare("`alerts`.`active`", "=", 1))
+
+	got, params := q.Table("`alerts_2024`").Build()
+
+	if want := Query("SELECT `alerts`.`id` FROM `alerts_2024` `alerts` WHERE `alerts`.`active` = ? "); got != want {
+		t.Errorf("Got: %q\nWant: %q", got, want)
+	}
+
+	if want := []interface{}{1}; !reflect.DeepEqual(params, want) {
+		t.Errorf("Got: %v\nWant: %v", params, want)
+	}
+
+	// the fields of a schema qualified table refer to its alias
+	q = SelectQuery("`analytics`.`alerts`").Fields("`analytics`.`alerts`.`id`").Where(Compare("`analytics`.`alerts`.`active`", "=", 1))
+
+	got, _ = q.Table("`archive`.`alerts_2024`").Build()
+
+	if want := Query("SELECT `alerts`.`id` FROM `archive`.`alerts_2024` `alerts` WHERE `alerts`.`active` = ? "); got != want {
+		t.Errorf("Got: %q\nWant: %q", got, want)
+	}
+
+	got, _ = q.Build()
+
+	if want := Query("SELECT `analytics`.`alerts`.`id` FROM `analytics`.`alerts` WHERE `analytics`.`alerts`.`active` = ? "); got != want {
+		t.Errorf("Got: %q\nWant: %q", got, want)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Got: no panic\nWant: panic for an invalid table name")
+		}
+	}()
+
+	q.Table("alerts; DROP TABLE users")
+}
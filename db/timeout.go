@@ -0,0 +1,55 @@
+// Copyright 2019 The DutchSec Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// Timeout returns a select option that cancels the query when it takes
+// longer than d, eg. tx.Selectx(&rows, q, db.Timeout(2*time.Second)).
+func Timeout(d time.Duration) selectOption {
+	return &timeoutOption{d}
+}
+
+type timeoutOption struct {
+	d time.Duration
+}
+
+// Wrap leaves the query as is, the timeout applies to the context the
+// query is executed with.
+func (o *timeoutOption) Wrap(query string, params []interface{}) (string, []interface{}) {
+	return query, params
+}
+
+// withTimeout returns the context with the deadlines of the Timeout options,
+// the cancel function releases all of them.
+func withTimeout(ctx context.Context, options []selectOption) (context.Context, context.CancelFunc) {
+	cancels := []context.CancelFunc{}
+
+	for _, option := range options {
+		if o, ok := option.(*timeoutOption); ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, o.d)
+			cancels = append(cancels, cancel)
+		}
+	}
+
+	return ctx, func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithTimeout(t *testing.T) {
+	ctx, cancel := withTimeout(context.Background(), []selectOption{Limit(1)})
+	if _, ok := ctx.Deadline(); ok {
+		t.Errorf("Got: deadline\nWant: no deadline without Timeout")
+	}
+
+	cancel()
+
+	ctx, cancel = withTimeout(context.Background(), []selectOption{Timeout(time.Hour), Timeout(time.Minute)})
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > time.Minute {
+		t.Errorf("Got: %v\nWant: deadline within a minute", deadline)
+	}
+
+	cancel()
+
+	if ctx.Err() != context.Canceled {
+		t.Errorf("Got: %v\nWant: %v", ctx.Err(), context.Canceled)
+	}
+}
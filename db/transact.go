@@ -0,0 +1,46 @@
+// Copyright 2019 The DutchSec Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package db
+
+import "context"
+
+// Transact runs fn in a new transaction. The transaction is committed when
+// fn succeeds and rolled back when fn returns an error or panics, the panic
+// continues after the rollback. fn shouldn't commit or roll back the
+// transaction itself.
+func Transact(ctx context.Context, db *DB, fn func(*Tx) error, opts ...TxOptionFunc) error {
+	return runTx(ctx, db, fn, opts...)
+}
+
+// runTx runs fn in a new transaction, committing it on success.
+func runTx(ctx context.Context, db *DB, fn func(*Tx) error, opts ...TxOptionFunc) error {
+	tx, err := db.Begin(ctx, opts...)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// recordDriver is a database driver recording the ends of its transactions
+// and the statements it executes.
+type recordDriver struct {
+	ends  []string
+	execs []string
+}
+
+func (d *recordDriver) Open(name string) (driver.Conn, error) {
+	return &recordConn{d}, nil
+}
+
+type recordConn struct {
+	d *recordDriver
+}
+
+func (c *recordConn) Prepare(query string) (driver.Stmt, error) {
+	return &recordStmt{c.d, query}, nil
+}
+
+func (c *recordConn) Close() error {
+	return nil
+}
+
+func (c *recordConn) Begin() (driver.Tx, error) {
+	return &recordTx{c.d}, nil
+}
+
+type recordStmt struct {
+	d     *recordDriver
+	query string
+}
+
+func (s *recordStmt) Close() error {
+	return nil
+}
+
+func (s *recordStmt) NumInput() int {
+	return -1
+}
+
+func (s *recordStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.execs = append(s.d.execs, s.query)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *recordStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+type recordTx struct {
+	d *recordDriver
+}
+
+func (tx *recordTx) Commit() error {
+	tx.d.ends = append(tx.d.ends, "commit")
+	return nil
+}
+
+func (tx *recordTx) Rollback() error {
+	tx.d.ends = append(tx.d.ends, "rollback")
+	return nil
+}
+
+func TestTransact(t *testing.T) {
+	d := &recordDriver{}
+	sql.Register("transact", d)
+
+	conn, err := sqlx.Open("transact", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := &DB{DB: conn}
+
+	errFailed := errors.New("failed")
+
+	if err := Transact(context.Background(), db, func(tx *Tx) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Transact(context.Background(), db, func(tx *Tx) error { return errFailed }); err != errFailed {
+		t.Errorf("Got: %v\nWant: %v", err, errFailed)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("Got: %v\nWant: the panic of fn", r)
+			}
+		}()
+
+		Transact(context.Background(), db, func(tx *Tx) error { panic("boom") })
+	}()
+
+	want := []string{"commit", "rollback", "rollback"}
+	if !reflect.DeepEqual(d.ends, want) {
+		t.Errorf("Got: %v\nWant: %v", d.ends, want)
+	}
+}
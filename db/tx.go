@@ -15,6 +15,8 @@ package db
 
 import (
 	"bytes"
+	"container/list"
+	"context"
 	"database/sql"
 	"fmt"
 	"reflect"
@@ -30,45 +32,220 @@ import (
 type Tx struct {
 	Tx *sqlx.Tx
 
+	// Logger logs the transaction and its queries, when nil the default
+	// logger of the package is used.
+	Logger Logger
+
+	// SlowThreshold is the duration after which the transaction or one
+	// of its queries is logged as slow, zero disables the warnings.
+	SlowThreshold time.Duration
+
+	// MaxStatements is the number of prepared statements the transaction
+	// caches, the least recently used statement is closed when the cache
+	// is full. Zero caches all statements until the transaction ends.
+	MaxStatements int
+
+	// OnQuery is called after each query the transaction executes, with
+	// the context of the call and the duration of the execution, eg. to
+	// record the latency in a trace. It is called while the transaction is
+	// locked and can't use the transaction.
+	OnQuery func(ctx context.Context, query string, dur time.Duration)
+
 	counter uint64
 
 	m          sync.Mutex
 	stacktrace string
 	time       time.Time
 
-	statementsCache sync.Map
+	// statements holds the cached statements by query, the elements of
+	// the recently used list, most recently used first.
+	statements     map[string]*list.Element
+	statementsUsed list.List
+
+	cacheHits      uint64
+	cacheMisses    uint64
+	cacheEvictions uint64
 
 	id string
 
 	queries []string
+
+	// queriesReset is the number of queries before the last
+	// ResetQueryCount, Queries and the counts start after them.
+	queriesReset int
+
+	// savepoints holds the names of the active savepoints, innermost
+	// last.
+	savepoints []string
+}
+
+// log returns the logger of the transaction.
+func (tx *Tx) log() Logger {
+	if tx.Logger != nil {
+		return tx.Logger
+	}
+
+	return log
 }
 
 func (tx *Tx) Preparex(query Query) (*sqlx.Stmt, error) {
+	return tx.PreparexContext(context.Background(), query)
+}
+
+// PreparexContext prepares the query, the context is used for the
+// preparation of the statement only. Statements are cached and closed when
+// the transaction ends, callers shouldn't close them.
+func (tx *Tx) PreparexContext(ctx context.Context, query Query) (*sqlx.Stmt, error) {
 	tx.m.Lock()
 	defer tx.m.Unlock()
 
-	return tx.preparex(query)
+	return tx.preparex(ctx, query)
 }
 
 // +checklocks:tx.m
-func (tx *Tx) preparex(query Query) (*sqlx.Stmt, error) {
+func (tx *Tx) preparex(ctx context.Context, query Query) (*sqlx.Stmt, error) {
 
 	tx.queries = append(tx.queries, string(query))
 
-	if stmt, ok := tx.statementsCache.Load(string(query)); ok {
-		//		return stmt.(*sqlx.Stmt), nil
-		_ = stmt
+	if e, ok := tx.statements[string(query)]; ok {
+		tx.cacheHits++
+		tx.statementsUsed.MoveToFront(e)
+		return e.Value.(*cachedStatement).stmt, nil
 	}
 
-	stmt, err := tx.Tx.Preparex(string(query))
+	tx.cacheMisses++
+
+	stmt, err := tx.Tx.PreparexContext(ctx, string(query))
 	if err != nil {
 		return nil, err
 	}
 
-	tx.statementsCache.Store(string(query), stmt)
+	if tx.statements == nil {
+		tx.statements = map[string]*list.Element{}
+	}
+
+	tx.statements[string(query)] = tx.statementsUsed.PushFront(&cachedStatement{string(query), stmt})
+
+	for tx.MaxStatements > 0 && len(tx.statements) > tx.MaxStatements {
+		tx.evictStatement()
+	}
+
 	return stmt, nil
 }
 
+type cachedStatement struct {
+	query string
+	stmt  *sqlx.Stmt
+}
+
+// evictStatement closes and removes the least recently used statement.
+// +checklocks:tx.m
+func (tx *Tx) evictStatement() {
+	e := tx.statementsUsed.Back()
+	cs := tx.statementsUsed.Remove(e).(*cachedStatement)
+	delete(tx.statements, cs.query)
+
+	tx.cacheEvictions++
+
+	if err := cs.stmt.Close(); err != nil {
+		tx.log().Errorf("[%d] Error closing statement: %s: %s", tx.counter, cs.query, err.Error())
+	}
+}
+
+// StatementCacheStats holds the usage of the prepared statement cache.
+type StatementCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64 // Statements closed because the cache was full.
+	Size      int
+}
+
+// CacheStats returns the usage of the prepared statement cache of the
+// transaction.
+func (tx *Tx) CacheStats() StatementCacheStats {
+	tx.m.Lock()
+	defer tx.m.Unlock()
+
+	return StatementCacheStats{
+		Hits:      tx.cacheHits,
+		Misses:    tx.cacheMisses,
+		Evictions: tx.cacheEvictions,
+		Size:      len(tx.statements),
+	}
+}
+
+// Queries returns the queries prepared in the transaction, in the order they
+// ran, eg. to inspect the queries of a test. Only the queries since the last
+// ResetQueryCount are returned.
+func (tx *Tx) Queries() []string {
+	tx.m.Lock()
+	defer tx.m.Unlock()
+
+	return append([]string{}, tx.queries[tx.queriesReset:]...)
+}
+
+// QueryCounts returns the number of times each query ran in the
+// transaction, a query running once per row of another one shows an N+1
+// problem. Only the queries since the last ResetQueryCount are counted.
+func (tx *Tx) QueryCounts() map[string]int {
+	tx.m.Lock()
+	defer tx.m.Unlock()
+
+	counts := map[string]int{}
+	for _, q := range tx.queries[tx.queriesReset:] {
+		counts[q]++
+	}
+
+	return counts
+}
+
+// QueryCount returns the number of queries that ran in the transaction
+// since the last ResetQueryCount, eg. to fail a test when a block of code
+// runs more queries than expected.
+func (tx *Tx) QueryCount() int {
+	tx.m.Lock()
+	defer tx.m.Unlock()
+
+	return len(tx.queries) - tx.queriesReset
+}
+
+// ResetQueryCount starts counting the queries of the transaction over, for
+// QueryCount, QueryCounts and Queries. The slow commit warning still lists
+// all queries.
+func (tx *Tx) ResetQueryCount() {
+	tx.m.Lock()
+	defer tx.m.Unlock()
+
+	tx.queriesReset = len(tx.queries)
+}
+
+// ClearStatementCache closes the cached prepared statements and resets the
+// cache statistics.
+func (tx *Tx) ClearStatementCache() {
+	tx.m.Lock()
+	defer tx.m.Unlock()
+
+	tx.closeStatements()
+
+	tx.cacheHits = 0
+	tx.cacheMisses = 0
+	tx.cacheEvictions = 0
+}
+
+// closeStatements closes and removes the cached prepared statements.
+// +checklocks:tx.m
+func (tx *Tx) closeStatements() {
+	for e := tx.statementsUsed.Front(); e != nil; e = e.Next() {
+		cs := e.Value.(*cachedStatement)
+		if err := cs.stmt.Close(); err != nil {
+			tx.log().Errorf("[%d] Error closing statement: %s: %s", tx.counter, cs.query, err.Error())
+		}
+	}
+
+	tx.statements = nil
+	tx.statementsUsed.Init()
+}
+
 func findMethod() string {
 	trace := make([]byte, 1024)
 
@@ -93,28 +270,64 @@ func (tx *Tx) Commit() error {
 		return sql.ErrTxDone
 	}
 
-	log.Infof("[%d] tx (%s)", tx.counter, findMethod())
-	defer log.Infof("[%d] tx finished (%s)", tx.counter, findMethod())
+	defer tx.closeStatements()
+
+	// finding the method is expensive, only do so when it is logged.
+	debug := debugEnabled(tx.log())
+	if debug {
+		tx.log().Debugf("[%d] tx (%s)", tx.counter, findMethod())
+		defer tx.log().Debugf("[%d] tx finished (%s)", tx.counter, findMethod())
+	}
 
 	err := tx.Tx.Commit()
 	if err == sql.ErrTxDone {
 		return err
 	} else if err != nil {
-		return fmt.Errorf("[%d] Could not commit transaction (%s): %s", tx.counter, findMethod(), err)
+		return fmt.Errorf("[%d] Could not commit transaction (%s): %w", tx.counter, findMethod(), err)
 	}
 
 	now := time.Now()
 
-	if now.Sub(tx.time) > 1*time.Second {
-		log.Warningf("[%d] Transaction commit (%s) took long, took: %s, queries=\n * %v.", tx.counter, findMethod(), now.Sub(tx.time), strings.Join(tx.queries, "\n * "))
+	if tx.isSlow(now.Sub(tx.time)) {
+		tx.log().Warningf("[%d] Transaction commit (%s) took long, took: %s, queries=\n * %v.\nStarted at:\n%s", tx.counter, findMethod(), now.Sub(tx.time), strings.Join(tx.queries, "\n * "), tx.stacktrace)
 	}
 
-	log.Debugf("[%d] Transaction commit (%s), took: %v. %p", tx.counter, findMethod(), now.Sub(tx.time), tx.Tx)
+	if debug {
+		tx.log().Debugf("[%d] Transaction commit (%s), took: %v. %p", tx.counter, findMethod(), now.Sub(tx.time), tx.Tx)
+	}
+
+	// the commit released all savepoints.
+	tx.savepoints = nil
 
 	tx.Tx = nil
 	return err
 }
 
+// Rebind converts the ? placeholders of the query to the bind type of the
+// driver, eg. $1 on postgres. The queries of Queryx are rebound by the
+// methods of the transaction.
+func (tx *Tx) Rebind(q string) string {
+	return tx.Tx.Rebind(q)
+}
+
+// rebind is Rebind for queries.
+func (tx *Tx) rebind(q Query) Query {
+	return Query(tx.Tx.Rebind(string(q)))
+}
+
+// onQuery calls the OnQuery hook, when set, with the duration of the query
+// since start.
+func (tx *Tx) onQuery(ctx context.Context, q Query, start time.Time) {
+	if tx.OnQuery != nil {
+		tx.OnQuery(ctx, string(q), time.Since(start))
+	}
+}
+
+// isSlow reports whether the duration exceeds the slow threshold.
+func (tx *Tx) isSlow(d time.Duration) bool {
+	return tx.SlowThreshold > 0 && d > tx.SlowThreshold
+}
+
 func (tx *Tx) Rollback() error {
 	tx.m.Lock()
 	defer tx.m.Unlock()
@@ -124,8 +337,11 @@ func (tx *Tx) Rollback() error {
 		return sql.ErrTxDone
 	}
 
+	defer tx.closeStatements()
+
 	err := tx.Tx.Rollback()
-	log.Errorf("[%d] Transaction rollback, took: %v (%s)", tx.counter, time.Since(tx.time), tx.id)
+	tx.savepoints = nil
+	tx.log().Errorf("[%d] Transaction rollback, took: %v (%s)", tx.counter, time.Since(tx.time), tx.id)
 	return err
 }
 
@@ -138,12 +354,6 @@ TableQuery("events").Fields("test1").RightJoin("otherTable").On(db.Equal("test",
 QueryActions() = Query()
 */
 
-/*
-func Count(field Field) Field {
-
-}
-*/
-
 /*
 type fields []Field
 
@@ -151,44 +361,259 @@ func (f *fields) String() {
 }
 */
 
-// Selectx TODO: NEEDS COMMENT INFO
+// Selectx selects the rows of the query into o. The options wrap the built
+// query in the order they are passed, so a Limit has to be passed before an
+// Offset. Unless o implements Selecter it has to be a pointer to a slice,
+// other destinations return ErrExpectedSlice, use Getx for a single row.
 func (tx *Tx) Selectx(o interface{}, qy Queryx, options ...selectOption) error {
+	return tx.SelectxContext(context.Background(), o, qy, options...)
+}
+
+// SelectxContext is Selectx with a context. Objects implementing Selecter
+// select their own rows and don't receive the context.
+func (tx *Tx) SelectxContext(ctx context.Context, o interface{}, qy Queryx, options ...selectOption) error {
 	tx.m.Lock()
 	defer tx.m.Unlock()
 
-	q, params := qy.Build()
+	ctx, cancel := withTimeout(ctx, options)
+	defer cancel()
+
+	q, params := qy.withOptions(options).Build()
+	tx.log().Debugf("[%d] Executing query: %s%s", tx.counter, q, formatParams(params))
 
 	start := time.Now()
 
 	defer func() {
 		now := time.Now()
-		if now.Sub(start) > 1*time.Second {
-			log.Warningf("[%d] Query took too long %v: %s (%s)", tx.counter, now.Sub(start), q, findMethod())
+		if tx.isSlow(now.Sub(start)) {
+			tx.log().Warningf("[%d] Query took too long %v: %s (%s)", tx.counter, now.Sub(start), q, findMethod())
 		}
 	}()
 
-	/*
-		for _, option := range options {
-			q, params = option.Wrap(q, params)
-		}
-	*/
+	q, params = wrapQuery(q, params, options)
+	q = tx.rebind(q)
 
 	if u, ok := o.(Selecter); ok {
 		err := u.Select(tx.Tx, q, params...)
+		tx.onQuery(ctx, q, start)
 		if err != nil {
-			log.Errorf("[%d] Error executing query: %s: %s (%s)", tx.counter, q, err.Error(), findMethod())
+			tx.log().Errorf("[%d] Error executing query: %s: %s (%s)", tx.counter, q, err.Error(), findMethod())
 		}
 
 		return err
 	}
 
-	stmt, err := tx.preparex(q)
+	// sqlx panics on a single struct, point to Getx instead
+	if reflect.Indirect(reflect.ValueOf(o)).Kind() != reflect.Slice {
+		return wrapErr("select", o, ErrExpectedSlice)
+	}
+
+	stmt, err := tx.preparex(ctx, q)
 	if err != nil {
-		log.Errorf("[%d] Error executing query: %s: %s (%s) (%s)", tx.counter, q, err.Error(), findMethod())
+		tx.log().Errorf("[%d] Error executing query: %s: %s (%s) (%s)", tx.counter, q, err.Error(), findMethod())
 		return err
 	}
 
-	return stmt.Select(o, params...)
+	if slice := reflect.ValueOf(o); slice.Kind() == reflect.Ptr && scansRows(slice.Elem().Type().Elem()) {
+		err = tx.scanRows(ctx, stmt, slice.Elem(), params)
+		tx.onQuery(ctx, q, start)
+		return err
+	}
+
+	err = stmt.SelectContext(ctx, o, params...)
+	tx.onQuery(ctx, q, start)
+	return err
+}
+
+var rowScannerType = reflect.TypeOf((*RowScanner)(nil)).Elem()
+
+// scansRows reports whether the elements of the type scan their own rows,
+// either T or *T of a slice.
+func scansRows(elem reflect.Type) bool {
+	return elem.Implements(rowScannerType) || reflect.PtrTo(elem).Implements(rowScannerType)
+}
+
+// scanRows appends the rows of the statement to the slice, scanned by the
+// RowScanner of the elements.
+// +checklocks:tx.m
+func (tx *Tx) scanRows(ctx context.Context, stmt *sqlx.Stmt, slice reflect.Value, params []interface{}) error {
+	rows, err := stmt.QueryxContext(ctx, params...)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	elem := slice.Type().Elem()
+	for rows.Next() {
+		// a []*T holds new pointers, a []T copies of the scanned rows
+		var v reflect.Value
+		if elem.Kind() == reflect.Ptr {
+			v = reflect.New(elem.Elem())
+		} else {
+			v = reflect.New(elem)
+		}
+
+		if err := v.Interface().(RowScanner).ScanRow(rows); err != nil {
+			return err
+		}
+
+		if elem.Kind() != reflect.Ptr {
+			v = v.Elem()
+		}
+
+		slice.Set(reflect.Append(slice, v))
+	}
+
+	return rows.Err()
+}
+
+// SelectxInto selects the rows into the slice dest points to, reusing its
+// capacity, eg. to poll the same query without allocating a new slice every
+// time. The length of the slice is reset to zero first.
+func (tx *Tx) SelectxInto(dest interface{}, qy Queryx, options ...selectOption) error {
+	return tx.SelectxIntoContext(context.Background(), dest, qy, options...)
+}
+
+// SelectxIntoContext is SelectxInto with a context.
+func (tx *Tx) SelectxIntoContext(ctx context.Context, dest interface{}, qy Queryx, options ...selectOption) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return ErrNoSlicePointer
+	}
+
+	// sqlx appends the rows to the slice
+	v.Elem().SetLen(0)
+
+	return tx.SelectxContext(ctx, dest, qy, options...)
+}
+
+// SelectAndCountx selects a page of the rows of the query into o and
+// returns the total count of the rows, eg. for a paginated table. The count
+// applies the options filtering the rows too, like Search, but ignores
+// Limit and Offset.
+func (tx *Tx) SelectAndCountx(o interface{}, qy Queryx, options ...selectOption) (int, error) {
+	return tx.SelectAndCountxContext(context.Background(), o, qy, options...)
+}
+
+// SelectAndCountxContext is SelectAndCountx with a context.
+func (tx *Tx) SelectAndCountxContext(ctx context.Context, o interface{}, qy Queryx, options ...selectOption) (int, error) {
+	ctx, cancel := withTimeout(ctx, options)
+	defer cancel()
+
+	if err := tx.SelectxContext(ctx, o, qy, options...); err != nil {
+		return 0, err
+	}
+
+	return tx.CountxContext(ctx, totalQuery(qy), options...)
+}
+
+// totalQuery returns the query counting all rows the query selects, without
+// its order and limit.
+func totalQuery(qy Queryx) Queryx {
+	builder := []interface{}{}
+	for _, expr := range qy.builder {
+		switch expr.(type) {
+		case orderByOption, limitOption:
+			continue
+		}
+
+		builder = append(builder, expr)
+	}
+
+	qy.builder = builder
+
+	// grouped queries are counted by countQuery
+	if !qy.grouped() {
+		qy.fields = []Field{"COUNT(*)"}
+	}
+
+	return qy
+}
+
+// wrapQuery applies the options to the query, in the order they are
+// passed. The locking options go last, as the locking clause follows the
+// LIMIT and OFFSET.
+func wrapQuery(q Query, params []interface{}, options []selectOption) (Query, []interface{}) {
+	ordered := []selectOption{}
+	locks := []selectOption{}
+	for _, option := range options {
+		if _, ok := option.(*lockOption); ok {
+			locks = append(locks, option)
+			continue
+		}
+
+		ordered = append(ordered, option)
+	}
+
+	for _, option := range append(ordered, locks...) {
+		var wrapped string
+		wrapped, params = option.Wrap(string(q), params)
+		q = Query(wrapped)
+	}
+
+	return q, params
+}
+
+// filterOptions returns the options filtering the rows, without the
+// options ordering, paging or locking them.
+func filterOptions(options []selectOption) []selectOption {
+	filters := []selectOption{}
+	for _, option := range options {
+		switch option.(type) {
+		case *limitCountOption, *offsetOption, *orderByFieldOption, *lockOption:
+			continue
+		}
+
+		filters = append(filters, option)
+	}
+
+	return filters
+}
+
+// Eachx calls fn for every row the query selects, without loading all
+// rows at once. The options wrap the query like with Selectx. The
+// transaction can't be used by fn.
+func (tx *Tx) Eachx(qy Queryx, fn func(rows *sqlx.Rows) error, options ...selectOption) error {
+	return tx.EachxContext(context.Background(), qy, fn, options...)
+}
+
+// EachxContext is Eachx with a context.
+func (tx *Tx) EachxContext(ctx context.Context, qy Queryx, fn func(rows *sqlx.Rows) error, options ...selectOption) error {
+	tx.m.Lock()
+	defer tx.m.Unlock()
+
+	ctx, cancel := withTimeout(ctx, options)
+	defer cancel()
+
+	q, params := qy.withOptions(options).Build()
+	q, params = wrapQuery(q, params, options)
+	q = tx.rebind(q)
+
+	stmt, err := tx.preparex(ctx, q)
+	if err != nil {
+		tx.log().Errorf("[%d] Error preparing query: %s: %s", tx.counter, q, err.Error())
+		return err
+	}
+
+	start := time.Now()
+
+	rows, err := stmt.QueryxContext(ctx, params...)
+	tx.onQuery(ctx, q, start)
+	if err != nil {
+		tx.log().Errorf("[%d] Error executing query: %s: %s", tx.counter, q, err.Error())
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
 }
 
 // Selectx TODO: NEEDS COMMENT INFO
@@ -202,7 +627,7 @@ func (tx *Tx) Selectx(o interface{}, qx Queryx, options ...selectOption) error {
 	defer func() {
 		now := time.Now()
 		if now.Sub(start) > 1*time.Second {
-			log.Warningf("Query took too long %v: %s", now.Sub(start), q)
+			tx.log().Warningf("Query took too long %v: %s", now.Sub(start), q)
 		}
 	}()
 
@@ -213,7 +638,7 @@ func (tx *Tx) Selectx(o interface{}, qx Queryx, options ...selectOption) error {
 	if u, ok := o.(Selecter); ok {
 		err := u.Select(tx.Tx, Query(q), params...)
 		if err != nil {
-			log.Errorf("Error executing query: %s: %s", q, err.Error())
+			tx.log().Errorf("Error executing query: %s: %s", q, err.Error())
 		}
 
 		return err
@@ -221,7 +646,7 @@ func (tx *Tx) Selectx(o interface{}, qx Queryx, options ...selectOption) error {
 
 	stmt, err := tx.Preparex(q)
 	if err != nil {
-		log.Errorf("Error executing query: %s: %s", q, err.Error())
+		tx.log().Errorf("Error executing query: %s: %s", q, err.Error())
 		return err
 	}
 
@@ -235,53 +660,110 @@ func (tx *Tx) Exists(qy Queryx) (bool, error) {
 	defer tx.m.Unlock()
 
 	q, params := qy.Build()
+	q = tx.rebind(q)
 
-	stmt, err := tx.preparex(Query(fmt.Sprintf("SELECT EXISTS(%s)", string(q))))
+	stmt, err := tx.preparex(context.Background(), Query(fmt.Sprintf("SELECT EXISTS(%s)", string(q))))
 	if err != nil {
-		log.Errorf("Error preparing query: %s: %s", q, err.Error())
+		tx.log().Errorf("Error preparing query: %s: %s", q, err.Error())
 		return false, err
 	}
 
 	exists := false
 
+	start := time.Now()
+
 	err = stmt.Get(&exists, params...)
+	tx.onQuery(context.Background(), q, start)
 	if err != nil {
-		log.Errorf("Error executing query: %s: %s", q, err.Error())
+		tx.log().Errorf("Error executing query: %s: %s", q, err.Error())
 		return false, err
 	}
 
 	return exists, err
 }
 
-// Countx TODO: NEEDS COMMENT INFO
-func (tx *Tx) Countx(qy Queryx) (int, error) {
+// Countx returns the count the query selects, eg. the query of a generated
+// Count<Type>s. Grouped queries count the number of groups. The options
+// filtering the rows are applied like with Selectx, so the count matches
+// the rows selected with the same options, eg.
+// tx.Countx(CountAlerts(), db.Search(AlertName, "disk")). Limit, Offset,
+// OrderBy and the locking options are ignored.
+func (tx *Tx) Countx(qy Queryx, options ...selectOption) (int, error) {
+	return tx.CountxContext(context.Background(), qy, options...)
+}
+
+// CountxContext is Countx with a context.
+func (tx *Tx) CountxContext(ctx context.Context, qy Queryx, options ...selectOption) (int, error) {
 	tx.m.Lock()
 	defer tx.m.Unlock()
 
-	q, params := qy.Build()
+	ctx, cancel := withTimeout(ctx, options)
+	defer cancel()
+
+	q, params := countQuery(qy, options...)
+	q = tx.rebind(q)
 
-	stmt, err := tx.preparex(q)
+	stmt, err := tx.preparex(ctx, q)
 	if err != nil {
-		log.Errorf("Error preparing query: %s: %s (%s)", q, err.Error(), tx.id)
+		tx.log().Errorf("Error preparing query: %s: %s (%s)", q, err.Error(), tx.id)
 		return 0, err
 	}
 
 	count := 0
 
-	err = stmt.Get(&count, params...)
+	start := time.Now()
+
+	err = stmt.GetContext(ctx, &count, params...)
+	tx.onQuery(ctx, q, start)
 	if err != nil {
-		log.Errorf("Error executing query: %s: %s (%s)", q, err.Error(), tx.id)
+		tx.log().Errorf("Error executing query: %s: %s (%s)", q, err.Error(), tx.id)
 	}
 
 	return count, err
 }
 
+// CountDistinctx counts the distinct values of the field in the rows the
+// query selects, the options are applied like with Countx.
+func (tx *Tx) CountDistinctx(qy Queryx, field Field, options ...selectOption) (int, error) {
+	qy, err := countDistinct(qy, field)
+	if err != nil {
+		return 0, err
+	}
+
+	return tx.CountxContext(context.Background(), qy, options...)
+}
+
+// countQuery builds the query of Countx with the options filtering the
+// rows, a grouped query selects a row per group and is counted as a
+// subquery.
+func countQuery(qy Queryx, options ...selectOption) (Query, []interface{}) {
+	q, params := qy.withOptions(options).Build()
+	q, params = wrapQuery(q, params, filterOptions(options))
+	if !qy.grouped() {
+		return q, params
+	}
+
+	return Query(fmt.Sprintf("SELECT COUNT(*) FROM (%s) q", q)), params
+}
+
+// countDistinct returns the query selecting the number of distinct values
+// of the field, instead of its fields.
+func countDistinct(qy Queryx, field Field) (Queryx, error) {
+	name, err := sanitize(string(field))
+	if err != nil {
+		return qy, err
+	}
+
+	qy.fields = []Field{Field(fmt.Sprintf("COUNT(DISTINCT %s)", name))}
+	return qy, nil
+}
+
 /*
 // Countx TODO: NEEDS COMMENT INFO
 func (tx *Tx) Countx(qx Queryx) (int, error) {
 	stmt, err := tx.Preparex(fmt.Sprintf("SELECT COUNT(*) FROM (%s) q", string(qx.Query)))
 	if err != nil {
-		log.Errorf("Error preparing query: %s: %s", qx.Query, err.Error())
+		tx.log().Errorf("Error preparing query: %s: %s", qx.Query, err.Error())
 		return 0, err
 	}
 
@@ -289,7 +771,7 @@ func (tx *Tx) Countx(qx Queryx) (int, error) {
 
 	err = stmt.Get(&count, qx.Params...)
 	if err != nil {
-		log.Errorf("Error executing query: %s: %s", qx.Query, err.Error())
+		tx.log().Errorf("Error executing query: %s: %s", qx.Query, err.Error())
 	}
 
 	return count, err
@@ -309,48 +791,169 @@ func (tx *Tx) Exec(qx Queryx) error {
 }
 */
 func (tx *Tx) Execute(qy Queryx) error {
+	return tx.ExecuteContext(context.Background(), qy)
+}
+
+// ExecuteContext is Execute with a context.
+func (tx *Tx) ExecuteContext(ctx context.Context, qy Queryx) error {
+	_, err := tx.ExecResultContext(ctx, qy)
+	return err
+}
+
+// ExecResult executes the query like Execute and returns its result, eg.
+// to get the number of rows a bulk update or delete changed.
+func (tx *Tx) ExecResult(qy Queryx) (sql.Result, error) {
+	return tx.ExecResultContext(context.Background(), qy)
+}
+
+// ExecResultContext is ExecResult with a context.
+func (tx *Tx) ExecResultContext(ctx context.Context, qy Queryx) (sql.Result, error) {
 	tx.m.Lock()
 	defer tx.m.Unlock()
 
 	q, params := qy.Build()
-	log.Debugf("[%d] Executing query: %s", tx.counter, q)
+	q = tx.rebind(q)
+	tx.log().Debugf("[%d] Executing query: %s%s", tx.counter, q, formatParams(params))
 
-	stmt, err := tx.preparex(q)
+	stmt, err := tx.preparex(ctx, q)
 	if err != nil {
-		log.Errorf("[%d] Error preparing query: %s: %s", tx.counter, q, err.Error())
-		return err
+		tx.log().Errorf("[%d] Error preparing query: %s: %s", tx.counter, q, err.Error())
+		return nil, err
 	}
 
-	_, err = stmt.Exec(params...)
+	start := time.Now()
+
+	result, err := stmt.ExecContext(ctx, params...)
+	tx.onQuery(ctx, q, start)
 	if err != nil {
-		log.Errorf("[%d] Error executing query: %s: %s", tx.counter, q, err.Error())
-		return err
+		tx.log().Errorf("[%d] Error executing query: %s: %s", tx.counter, q, err.Error())
+		return nil, err
 	}
 
-	return err
+	return result, nil
 }
 
-// Getx TODO: NEEDS COMMENT INFO
+// Getx gets the single row of the query into o. Objects implementing
+// Getter get their own row, other objects are scanned by sqlx.
 func (tx *Tx) Getx(o interface{}, qy Queryx) error {
+	return tx.GetxContext(context.Background(), o, qy)
+}
 
+// GetxContext is Getx with a context. Objects implementing Getter get their
+// own row and don't receive the context, other objects are scanned by sqlx.
+func (tx *Tx) GetxContext(ctx context.Context, o interface{}, qy Queryx) error {
 	tx.m.Lock()
 	defer tx.m.Unlock()
 
 	q, params := qy.Build()
-	log.Debugf("[%d] Executing query: %s", tx.counter, q)
+	q = tx.rebind(q)
+	tx.log().Debugf("[%d] Executing query: %s%s", tx.counter, q, formatParams(params))
 
 	if u, ok := o.(Getter); ok {
 		err := u.Get(tx.Tx, q, params)
 		if IsNoRowsErr(err) {
 		} else if err != nil {
-			log.Errorf("[%d] Error executing query: %s: %s", tx.counter, q, err.Error())
+			tx.log().Errorf("[%d] Error executing query: %s: %s", tx.counter, q, err.Error())
 		}
 
+		return wrapErr("get", o, err)
+	}
+
+	stmt, err := tx.preparex(ctx, q)
+	if err != nil {
+		tx.log().Errorf("[%d] Error preparing query: %s: %s", tx.counter, q, err.Error())
 		return err
 	}
 
-	log.Error("No getter found for object: %s", reflect.TypeOf(o))
-	return ErrNoGetterFound
+	start := time.Now()
+
+	err = stmt.GetContext(ctx, o, params...)
+	tx.onQuery(ctx, q, start)
+	if IsNoRowsErr(err) {
+	} else if err != nil {
+		tx.log().Errorf("[%d] Error executing query: %s: %s", tx.counter, q, err.Error())
+	}
+
+	return wrapErr("get", o, err)
+}
+
+// Getxraw is Getx with the query and its parameters as is, like RawQuery,
+// for one-off queries that still use the statement cache and the logging.
+func (tx *Tx) Getxraw(o interface{}, query string, params ...interface{}) error {
+	return tx.GetxrawContext(context.Background(), o, query, params...)
+}
+
+// GetxrawContext is Getxraw with a context.
+func (tx *Tx) GetxrawContext(ctx context.Context, o interface{}, query string, params ...interface{}) error {
+	return tx.GetxContext(ctx, o, RawQuery(Query(query), params...))
+}
+
+// Queryx executes the query with the parameters as is, like RawQuery, and
+// returns the rows. Unlike the Queryx of sqlx the statement is prepared
+// through the statement cache and the query is logged. The rows have to be
+// closed before the transaction is used again.
+func (tx *Tx) Queryx(query string, params ...interface{}) (*sqlx.Rows, error) {
+	return tx.QueryxContext(context.Background(), query, params...)
+}
+
+// QueryxContext is Queryx with a context.
+func (tx *Tx) QueryxContext(ctx context.Context, query string, params ...interface{}) (*sqlx.Rows, error) {
+	tx.m.Lock()
+	defer tx.m.Unlock()
+
+	q, params := RawQuery(Query(query), params...).Build()
+	q = tx.rebind(q)
+	tx.log().Debugf("[%d] Executing query: %s%s", tx.counter, q, formatParams(params))
+
+	stmt, err := tx.preparex(ctx, q)
+	if err != nil {
+		tx.log().Errorf("[%d] Error preparing query: %s: %s", tx.counter, q, err.Error())
+		return nil, err
+	}
+
+	start := time.Now()
+
+	rows, err := stmt.QueryxContext(ctx, params...)
+	tx.onQuery(ctx, q, start)
+	if err != nil {
+		tx.log().Errorf("[%d] Error executing query: %s: %s", tx.counter, q, err.Error())
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// Returningx executes the query and scans the row it returns into o, eg.
+// the row of an INSERT ... RETURNING on postgres built with RawQuery.
+// Unlike Getx the Getter of o isn't used, the row is scanned by sqlx.
+func (tx *Tx) Returningx(o interface{}, qy Queryx) error {
+	return tx.ReturningxContext(context.Background(), o, qy)
+}
+
+// ReturningxContext is Returningx with a context.
+func (tx *Tx) ReturningxContext(ctx context.Context, o interface{}, qy Queryx) error {
+	tx.m.Lock()
+	defer tx.m.Unlock()
+
+	q, params := qy.Build()
+	q = tx.rebind(q)
+	tx.log().Debugf("[%d] Executing query: %s%s", tx.counter, q, formatParams(params))
+
+	stmt, err := tx.preparex(ctx, q)
+	if err != nil {
+		tx.log().Errorf("[%d] Error preparing query: %s: %s", tx.counter, q, err.Error())
+		return err
+	}
+
+	start := time.Now()
+
+	err = stmt.GetContext(ctx, o, params...)
+	tx.onQuery(ctx, q, start)
+	if err != nil && !IsNoRowsErr(err) {
+		tx.log().Errorf("[%d] Error executing query: %s: %s", tx.counter, q, err.Error())
+	}
+
+	return wrapErr("returning", o, err)
 }
 
 // Getx TODO: NEEDS COMMENT INFO
@@ -359,13 +962,13 @@ func (tx *Tx) Getx(o interface{}, qx Queryx) error {
 	if u, ok := o.(Getter); ok {
 		err := u.Get(tx.Tx, qx)
 		if err != nil {
-			log.Errorf("Error executing query: %s: %s", qx.Query, err.Error())
+			tx.log().Errorf("Error executing query: %s: %s", qx.Query, err.Error())
 		}
 
 		return err
 	}
 
-	log.Error("No getter found for object: %s", reflect.TypeOf(o))
+	tx.log().Errorf("No getter found for object: %s", reflect.TypeOf(o))
 	return ErrNoGetterFound
 }
 
@@ -374,13 +977,13 @@ func (tx *Tx) Get(o interface{}, qx Queryx) error {
 	if u, ok := o.(Getter); ok {
 		err := u.Get(tx.Tx, qx)
 		if err != nil {
-			log.Errorf("Error executing query: %s: %s", qx.Query, err.Error())
+			tx.log().Errorf("Error executing query: %s: %s", qx.Query, err.Error())
 		}
 
 		return err
 	}
 
-	log.Error("No getter found for object: %s", reflect.TypeOf(o))
+	tx.log().Errorf("No getter found for object: %s", reflect.TypeOf(o))
 	return ErrNoGetterFound
 }
 */
@@ -390,58 +993,95 @@ func (tx *Tx) NamedExec(query string, arg interface{}) (sql.Result, error) {
 	tx.m.Lock()
 	defer tx.m.Unlock()
 
-	log.Debugf("[%d] Executing query: %s", tx.counter, query)
+	tx.log().Debugf("[%d] Executing query: %s", tx.counter, query)
 
 	return tx.Tx.NamedExec(query, arg)
 }
 
 // Update TODO: NEEDS COMMENT INFO
 func (tx *Tx) InsertOrUpdate(o interface{}) error {
-	log.Debugf("[%d] Executing insert or update", tx.counter)
+	tx.log().Debugf("[%d] Executing insert or update", tx.counter)
 	if u, ok := o.(InsertOrUpdater); ok {
-		return u.InsertOrUpdate(tx.Tx)
+		return wrapErr("insert or update", o, u.InsertOrUpdate(tx.Tx))
 	}
 
-	log.Error("No InsertOrUpdate found for object: %s", reflect.TypeOf(o))
+	tx.log().Errorf("No InsertOrUpdate found for object: %s", reflect.TypeOf(o))
 	return ErrNoInsertOrUpdaterFound
 }
 
+// InsertOrUpdateReturning inserts or updates the object and reads the stored
+// row back into it, including the values set by the database.
+func (tx *Tx) InsertOrUpdateReturning(o interface{}) error {
+	tx.log().Debugf("[%d] Executing insert or update returning", tx.counter)
+	if u, ok := o.(InsertOrUpdateReturner); ok {
+		return wrapErr("insert or update", o, u.InsertOrUpdateReturning(tx.Tx))
+	}
+
+	tx.log().Errorf("No InsertOrUpdateReturning found for object: %s", reflect.TypeOf(o))
+	return ErrNoInsertOrUpdateReturnerFound
+}
+
+// InsertIgnore inserts the object, unless it conflicts with an existing row,
+// eg. a row with the same key. The existing row is left as is.
+func (tx *Tx) InsertIgnore(o interface{}) error {
+	tx.log().Debugf("[%d] Executing insert ignore", tx.counter)
+	if u, ok := o.(InsertIgnorer); ok {
+		return wrapErr("insert", o, u.InsertIgnore(tx.Tx))
+	}
+
+	tx.log().Errorf("No InsertIgnore found for object: %s", reflect.TypeOf(o))
+	return ErrNoInsertIgnorerFound
+}
+
 // Update TODO: NEEDS COMMENT INFO
 func (tx *Tx) Update(o interface{}) error {
-	log.Debugf("[%d] Executing update", tx.counter)
+	tx.log().Debugf("[%d] Executing update", tx.counter)
 	if u, ok := o.(Updater); ok {
-		return u.Update(tx.Tx)
+		return wrapErr("update", o, u.Update(tx.Tx))
 	}
 
-	log.Error("No updater found for object: %s", reflect.TypeOf(o))
+	tx.log().Errorf("No updater found for object: %s", reflect.TypeOf(o))
 	return ErrNoUpdaterFound
 }
 
 // Delete TODO: NEEDS COMMENT INFO
 func (tx *Tx) Delete(o interface{}) error {
-	log.Debugf("[%d] Executing delete", tx.counter)
+	tx.log().Debugf("[%d] Executing delete", tx.counter)
 
 	if u, ok := o.(Deleter); ok {
-		return u.Delete(tx.Tx)
+		return wrapErr("delete", o, u.Delete(tx.Tx))
 	}
 
-	log.Error("No deleter found for object: %s", reflect.TypeOf(o))
+	tx.log().Errorf("No deleter found for object: %s", reflect.TypeOf(o))
 	return ErrNoDeleterFound
 }
 
+// DeleteHard permanently deletes the object, using its HardDeleter
+// implementation.
+func (tx *Tx) DeleteHard(o interface{}) error {
+	tx.log().Debugf("[%d] Executing hard delete", tx.counter)
+
+	if u, ok := o.(HardDeleter); ok {
+		return wrapErr("delete", o, u.DeleteHard(tx.Tx))
+	}
+
+	tx.log().Errorf("No hard deleter found for object: %s", reflect.TypeOf(o))
+	return ErrNoHardDeleterFound
+}
+
 // Insert TODO: NEEDS COMMENT INFO
 func (tx *Tx) Insert(o interface{}) error {
-	log.Debugf("[%d] Executing insert", tx.counter)
+	tx.log().Debugf("[%d] Executing insert", tx.counter)
 
 	if u, ok := o.(Inserter); ok {
 		err := u.Insert(tx.Tx)
 		if err != nil {
-			log.Error(err.Error())
+			tx.log().Errorf("[%d] Error executing insert: %s: %s", tx.counter, reflect.TypeOf(o), err)
 		}
-		return err
+		return wrapErr("insert", o, err)
 	}
 
-	log.Error("No inserter found for object: %s", reflect.TypeOf(o))
+	tx.log().Errorf("No inserter found for object: %s", reflect.TypeOf(o))
 	return ErrNoInserterFound
 }
 
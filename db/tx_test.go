@@ -0,0 +1,399 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestCountDistinct(t *testing.T) {
+	q := SelectQuery("alerts").Fields("*").Where(Compare("alerts.status", "=", 1))
+
+	qy, err := countDistinct(q, "alerts.user_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, params := qy.Build()
+
+	want := Query("SELECT COUNT(DISTINCT alerts.user_id) FROM alerts WHERE alerts.status = ? ")
+	if got != want {
+		t.Errorf("Got: %s\nWant: %s", got, want)
+	}
+
+	if len(params) != 1 || params[0] != 1 {
+		t.Errorf("Got params: %v\nWant: [1]", params)
+	}
+
+	if _, err := countDistinct(q, "user_id) FROM users; --"); err == nil {
+		t.Errorf("Got: no error\nWant: error for an invalid field")
+	}
+}
+
+func TestCountQueryOptions(t *testing.T) {
+	q := SelectQuery("alerts").Fields("COUNT(*)").Where(Compare("active", "=", 1))
+
+	got, params := countQuery(q, Search("name", "disk"), OrderBy("name", "ASC"), Limit(20), Offset(40), ForUpdate())
+
+	if want := Query("SELECT COUNT(*) FROM alerts WHERE (active = ?) AND name LIKE ? ESCAPE '!' "); got != want {
+		t.Errorf("Got: %q\nWant: %q", got, want)
+	}
+
+	if want := []interface{}{1, "%disk%"}; !reflect.DeepEqual(params, want) {
+		t.Errorf("Got: %v\nWant: %v", params, want)
+	}
+
+	grouped := SelectQuery("alerts").Fields("status").GroupBy("status")
+
+	got, _ = countQuery(grouped, Search("name", "disk"))
+
+	if want := Query("SELECT COUNT(*) FROM (SELECT status FROM alerts WHERE name LIKE ? ESCAPE '!' GROUP BY status ) q"); got != want {
+		t.Errorf("Got: %q\nWant: %q", got, want)
+	}
+}
+
+func TestInsertOrUpdateReturningNotImplemented(t *testing.T) {
+	tx := &Tx{}
+
+	if err := tx.InsertOrUpdateReturning(&struct{}{}); err != ErrNoInsertOrUpdateReturnerFound {
+		t.Errorf("Got: %v\nWant: %v", err, ErrNoInsertOrUpdateReturnerFound)
+	}
+}
+
+func TestInsertIgnoreNotImplemented(t *testing.T) {
+	tx := &Tx{}
+
+	if err := tx.InsertIgnore(&struct{}{}); err != ErrNoInsertIgnorerFound {
+		t.Errorf("Got: %v\nWant: %v", err, ErrNoInsertIgnorerFound)
+	}
+}
+
+func TestRawQuery(t *testing.T) {
+	got, params := RawQuery("INSERT INTO alerts (name) VALUES (?) RETURNING id", "disk").Build()
+
+	if want := Query("INSERT INTO alerts (name) VALUES (?) RETURNING id"); got != want {
+		t.Errorf("Got: %s\nWant: %s", got, want)
+	}
+
+	if len(params) != 1 || params[0] != "disk" {
+		t.Errorf("Got params: %v\nWant: [disk]", params)
+	}
+}
+
+func TestOnQuery(t *testing.T) {
+	d := &recordDriver{}
+	sql.Register("onquery", d)
+
+	conn, err := sqlx.Open("onquery", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queries := []string{}
+
+	db := &DB{
+		DB: conn,
+		OnQuery: func(ctx context.Context, query string, dur time.Duration) {
+			queries = append(queries, query)
+		},
+	}
+
+	tx, err := db.Begin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tx.Rollback()
+
+	if err := tx.Execute(RawQuery("UPDATE alerts SET active = ?", false)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tx.Countx(RawQuery("SELECT COUNT(*) FROM alerts")); err == nil {
+		t.Fatal("Got: no error\nWant: error of the driver")
+	}
+
+	want := []string{"UPDATE alerts SET active = ?", "SELECT COUNT(*) FROM alerts"}
+	if !reflect.DeepEqual(queries, want) {
+		t.Errorf("Got: %v\nWant: %v", queries, want)
+	}
+
+	if !reflect.DeepEqual(d.execs, want[:1]) {
+		t.Errorf("Got: %v\nWant: %v", d.execs, want[:1])
+	}
+}
+
+func TestQueries(t *testing.T) {
+	d := &recordDriver{}
+	sql.Register("queries", d)
+
+	conn, err := sqlx.Open("queries", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := Begin(context.Background(), conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tx.Rollback()
+
+	for _, q := range []Query{"UPDATE alerts SET active = 0", "DELETE FROM users", "UPDATE alerts SET active = 0"} {
+		if err := tx.Execute(RawQuery(q)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"UPDATE alerts SET active = 0", "DELETE FROM users", "UPDATE alerts SET active = 0"}
+	if got := tx.Queries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Got: %v\nWant: %v", got, want)
+	}
+
+	counts := map[string]int{"UPDATE alerts SET active = 0": 2, "DELETE FROM users": 1}
+	if got := tx.QueryCounts(); !reflect.DeepEqual(got, counts) {
+		t.Errorf("Got: %v\nWant: %v", got, counts)
+	}
+}
+
+func TestQueryCount(t *testing.T) {
+	d := &recordDriver{}
+	sql.Register("querycount", d)
+
+	conn, err := sqlx.Open("querycount", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := Begin(context.Background(), conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tx.Rollback()
+
+	if err := tx.Execute(RawQuery("DELETE FROM users")); err != nil {
+		t.Fatal(err)
+	}
+
+	tx.ResetQueryCount()
+
+	if got := tx.QueryCount(); got != 0 {
+		t.Errorf("Got: %d queries\nWant: 0", got)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := tx.Execute(RawQuery("UPDATE alerts SET active = 0")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := tx.QueryCount(); got != 3 {
+		t.Errorf("Got: %d queries\nWant: 3", got)
+	}
+
+	counts := map[string]int{"UPDATE alerts SET active = 0": 3}
+	if got := tx.QueryCounts(); !reflect.DeepEqual(got, counts) {
+		t.Errorf("Got: %v\nWant: %v", got, counts)
+	}
+}
+
+func TestRawQueryx(t *testing.T) {
+	d := &recordDriver{}
+	sql.Register("rawqueryx", d)
+
+	conn, err := sqlx.Open("rawqueryx", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := Begin(context.Background(), conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tx.Rollback()
+
+	// the fake driver doesn't support queries, only the preparing is checked
+	if _, err := tx.Queryx("SELECT id FROM alerts WHERE name = ?", "disk"); err == nil {
+		t.Errorf("Got: no error\nWant: error of the driver")
+	}
+
+	var id int
+	if err := tx.Getxraw(&id, "SELECT id FROM alerts WHERE name = ?", "disk"); err == nil {
+		t.Errorf("Got: no error\nWant: error of the driver")
+	}
+
+	want := StatementCacheStats{Hits: 1, Misses: 1, Size: 1}
+	if got := tx.CacheStats(); got != want {
+		t.Errorf("Got: %+v\nWant: %+v", got, want)
+	}
+
+	if got := tx.QueryCounts(); got["SELECT id FROM alerts WHERE name = ?"] != 2 {
+		t.Errorf("Got: %v\nWant: 2 queries", got)
+	}
+}
+
+func TestSelectxInto(t *testing.T) {
+	d := &recordDriver{}
+	sql.Register("selectxinto", d)
+
+	conn, err := sqlx.Open("selectxinto", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := Begin(context.Background(), conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tx.Rollback()
+
+	ids := []int{}
+	if err := tx.SelectxInto(ids, RawQuery("SELECT id FROM alerts")); err != ErrNoSlicePointer {
+		t.Errorf("Got: %v\nWant: %v", err, ErrNoSlicePointer)
+	}
+
+	ids = make([]int, 3, 10)
+
+	// the fake driver doesn't support queries, the slice is reset before
+	tx.SelectxInto(&ids, RawQuery("SELECT id FROM alerts"))
+
+	if len(ids) != 0 || cap(ids) != 10 {
+		t.Errorf("Got: len %d, cap %d\nWant: len 0, cap 10", len(ids), cap(ids))
+	}
+}
+
+func TestSelectxExpectedSlice(t *testing.T) {
+	d := &recordDriver{}
+	sql.Register("selectxslice", d)
+
+	conn, err := sqlx.Open("selectxslice", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := Begin(context.Background(), conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tx.Rollback()
+
+	var alert struct {
+		ID int `db:"id"`
+	}
+
+	if err := tx.Selectx(&alert, RawQuery("SELECT id FROM alerts")); !errors.Is(err, ErrExpectedSlice) {
+		t.Errorf("Got: %v\nWant: %v", err, ErrExpectedSlice)
+	}
+
+	if n := tx.QueryCount(); n != 0 {
+		t.Errorf("Got: %d queries\nWant: 0", n)
+	}
+}
+
+func TestRebind(t *testing.T) {
+	d := &recordDriver{}
+	sql.Register("rebind", d)
+
+	conn, err := sql.Open("rebind", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := Begin(context.Background(), sqlx.NewDb(conn, "postgres"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tx.Rollback()
+
+	if err := tx.Execute(RawQuery("UPDATE alerts SET name = ? WHERE id = ?", "name", 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"UPDATE alerts SET name = $1 WHERE id = $2"}
+	if !reflect.DeepEqual(d.execs, want) {
+		t.Errorf("Got: %v\nWant: %v", d.execs, want)
+	}
+}
+
+func TestExecResult(t *testing.T) {
+	d := &recordDriver{}
+	sql.Register("execresult", d)
+
+	conn, err := sqlx.Open("execresult", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := Begin(context.Background(), conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tx.Rollback()
+
+	result, err := tx.ExecResult(RawQuery("DELETE FROM alerts WHERE active = ?", false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Errorf("Got: %d rows affected\nWant: 1", n)
+	}
+}
+
+// scannedAlert scans its name in upper case, to tell ScanRow from sqlx.
+type scannedAlert struct {
+	ID   int
+	Name string
+}
+
+func (a *scannedAlert) ScanRow(rows *sqlx.Rows) error {
+	if err := rows.Scan(&a.ID, &a.Name); err != nil {
+		return err
+	}
+
+	a.Name = strings.ToUpper(a.Name)
+	return nil
+}
+
+func TestSelectxRowScanner(t *testing.T) {
+	tx, mock := mockTx(t)
+
+	q := "SELECT id, name FROM alerts"
+
+	mock.ExpectPrepare(q).ExpectQuery().WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "disk").AddRow(2, "cpu"))
+
+	alerts := []scannedAlert{}
+	if err := tx.Selectx(&alerts, RawQuery(Query(q))); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []scannedAlert{{1, "DISK"}, {2, "CPU"}}; !reflect.DeepEqual(alerts, want) {
+		t.Errorf("Got: %v\nWant: %v", alerts, want)
+	}
+
+	mock.ExpectQuery(q).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "net"))
+
+	pointers := []*scannedAlert{}
+	if err := tx.Selectx(&pointers, RawQuery(Query(q))); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pointers) != 1 || *pointers[0] != (scannedAlert{3, "NET"}) {
+		t.Errorf("Got: %v\nWant: [&{3 NET}]", pointers)
+	}
+}
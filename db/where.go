@@ -19,9 +19,38 @@ type whereOption struct {
 
 type where Operator
 
+// Where filters the query on the operator, when the query is already
+// filtered both conditions have to match.
 func (tq Queryx) Where(operator Operator) Queryx {
-	w := where(operator)
+	return tq.And(operator)
+}
+
+// And adds the operator to the conditions of the query, both have to match.
+func (tq Queryx) And(operator Operator) Queryx {
+	return tq.combine(operator, And)
+}
+
+// Or adds the operator to the conditions of the query, either has to match.
+func (tq Queryx) Or(operator Operator) Queryx {
+	return tq.combine(operator, Or)
+}
+
+// combine joins the operator with the existing where of the query, using
+// fn. The builder is copied, as it may be shared with the query it was
+// derived from.
+func (tq Queryx) combine(operator Operator, fn func(...Operator) Operator) Queryx {
+	builder := make([]interface{}, len(tq.builder))
+	copy(builder, tq.builder)
+
+	for i, expr := range builder {
+		if w, ok := expr.(where); ok {
+			builder[i] = where(fn(Operator(w), operator))
+
+			tq.builder = builder
+			return tq
+		}
+	}
 
-	tq.builder = append(tq.builder, w)
+	tq.builder = append(builder, where(operator))
 	return tq
 }
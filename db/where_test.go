@@ -22,3 +22,55 @@ func TestWhere(t *testing.T) {
 		}
 	}
 }
+
+var (
+	TestSetWhereAndOr = []Set{
+		{
+			Query: SelectQuery("alerts").Fields("*").Where(Compare("status", "=", 42)),
+			Want:  Query("SELECT * FROM alerts WHERE status = ? "),
+		},
+		{
+			Query: SelectQuery("alerts").Fields("*").Where(Compare("status", "=", 42)).Where(Compare("name", "LIKE", "a%")),
+			Want:  Query("SELECT * FROM alerts WHERE (status = ?)  AND (name LIKE ?)  "),
+		},
+		{
+			Query: SelectQuery("alerts").Fields("*").Where(Compare("status", "=", 42)).And(Compare("name", "LIKE", "a%")).Or(Compare("id", ">", 1)),
+			Want:  Query("SELECT * FROM alerts WHERE ((status = ?)  AND (name LIKE ?) ) OR (id > ?) "),
+		},
+		{
+			Query: SelectQuery("alerts").Fields("*").Or(Compare("id", "<>", 1)),
+			Want:  Query("SELECT * FROM alerts WHERE id <> ? "),
+		},
+	}
+)
+
+func TestWhereAndOr(t *testing.T) {
+	for _, ts := range TestSetWhereAndOr {
+		got, _ := ts.Query.Build()
+
+		if got != ts.Want {
+			t.Errorf("Got: %s\nWant: %s", got, ts.Want)
+		}
+	}
+}
+
+func TestWhereShared(t *testing.T) {
+	q := SelectQuery("alerts").Fields("*").Where(Compare("status", "=", 42))
+
+	q.And(Compare("id", "=", 1))
+
+	want := Query("SELECT * FROM alerts WHERE status = ? ")
+	if got, _ := q.Build(); got != want {
+		t.Errorf("Got: %s\nWant: %s", got, want)
+	}
+}
+
+func TestCompareUnsupported(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Got: no panic\nWant: panic for unsupported operator")
+		}
+	}()
+
+	Compare("status", "; DROP TABLE alerts", 1)
+}
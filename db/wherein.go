@@ -0,0 +1,54 @@
+// Copyright 2019 The DutchSec Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package db
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// WhereIn returns a select option that filters the rows on the field
+// having one of the values of the slice, eg.
+// tx.Selectx(&rows, q, db.WhereIn(AlertID, ids)). The condition is added
+// to the where of the query, an empty slice selects no rows. It panics on
+// invalid field names.
+func WhereIn(field Field, values interface{}) selectOption {
+	if _, err := sanitize(string(field)); err != nil {
+		panic(fmt.Sprintf("db: invalid where in field: %s", err))
+	}
+
+	return &whereInOption{field, values}
+}
+
+type whereInOption struct {
+	field  Field
+	values interface{}
+}
+
+// Wrap adds the IN condition to the where of the query, sqlx.In expands
+// the placeholder to one for every value.
+func (o *whereInOption) Wrap(query string, params []interface{}) (string, []interface{}) {
+	if v := reflect.ValueOf(o.values); v.Kind() == reflect.Slice && v.Len() == 0 {
+		return addCondition(query, params, "1=0")
+	}
+
+	cond, args, err := sqlx.In(fmt.Sprintf("%s IN (?)", o.field), o.values)
+	if err != nil {
+		panic(fmt.Sprintf("db: where in %s: %s", o.field, err))
+	}
+
+	return addCondition(query, params, cond, args...)
+}
@@ -0,0 +1,25 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWhereIn(t *testing.T) {
+	q, params := SelectQuery("alerts").Fields("*").Where(Compare("status", "=", 1)).Build()
+
+	got, params := WhereIn("id", []int{4, 5, 6}).Wrap(string(q), params)
+
+	want := "SELECT * FROM alerts WHERE (status = ?) AND id IN (?, ?, ?) "
+	if got != want {
+		t.Errorf("Got: %q\nWant: %q", got, want)
+	}
+
+	if want := []interface{}{1, 4, 5, 6}; !reflect.DeepEqual(params, want) {
+		t.Errorf("Got params: %v\nWant: %v", params, want)
+	}
+
+	if got, params := WhereIn("id", []int{}).Wrap("SELECT * FROM alerts ", nil); got != "SELECT * FROM alerts WHERE 1=0 " || len(params) != 0 {
+		t.Errorf("Got: %q %v\nWant: %q", got, params, "SELECT * FROM alerts WHERE 1=0 ")
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright 2019 The DutchSec Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package db
+
+import "reflect"
+
+// IsZero reports whether v holds the zero value of its type, eg. to skip
+// the fields that weren't set. Empty slices and maps aren't nil and
+// pointers to a zero value aren't zero.
+func IsZero(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	return reflect.DeepEqual(v, reflect.Zero(reflect.TypeOf(v)).Interface())
+}
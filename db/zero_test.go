@@ -0,0 +1,31 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsZero(t *testing.T) {
+	zero := 0
+
+	for _, ts := range []struct {
+		V    interface{}
+		Want bool
+	}{
+		{nil, true},
+		{0, true},
+		{"", true},
+		{time.Time{}, true},
+		{[]int(nil), true},
+		{(*int)(nil), true},
+		{1, false},
+		{"disk", false},
+		{time.Now(), false},
+		{[]int{}, false},
+		{&zero, false},
+	} {
+		if got := IsZero(ts.V); got != ts.Want {
+			t.Errorf("%#v\nGot: %t\nWant: %t", ts.V, got, ts.Want)
+		}
+	}
+}